@@ -8,18 +8,48 @@ import (
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/aof"
+	"github.com/hirotake111/redisclient/internal/cache"
+	"github.com/hirotake111/redisclient/internal/cli"
+	"github.com/hirotake111/redisclient/internal/clipboard"
+	"github.com/hirotake111/redisclient/internal/command"
 	"github.com/hirotake111/redisclient/internal/config"
+	"github.com/hirotake111/redisclient/internal/decoder"
 	"github.com/hirotake111/redisclient/internal/logger"
 	"github.com/hirotake111/redisclient/internal/model"
+	"github.com/hirotake111/redisclient/internal/rdb"
+	"github.com/hirotake111/redisclient/internal/source"
 	"github.com/redis/go-redis/v9"
 )
 
+const (
+	cacheMaxEntries = 1000
+	cacheMaxBytes   = 64 << 20 // 64MB
+)
+
 var (
 	Version = "development"
 )
 
 func main() {
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		if err := logger.Initialize(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+			os.Exit(cli.ExitError)
+		}
+		os.Exit(cli.Run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	showVersion := flag.Bool("version", false, "Show version number")
+	rdbPath := flag.String("rdb", "", "Browse an RDB file, read-only, instead of connecting to Redis")
+	aofPath := flag.String("aof", "", "Inspect an append-only file, or a directory with a multi-part AOF manifest, instead of connecting to Redis")
+	profile := flag.String("profile", "", "Connect to the server of a connection profile instead of REDIS_URL")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: red [flags], or red <command> [flags] [args]\n\nFlags:\n")
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output())
+		cli.Usage(flag.CommandLine.Output())
+	}
 	flag.Parse()
 
 	if showVersion != nil && *showVersion {
@@ -42,14 +72,69 @@ func main() {
 		fmt.Printf("Failed to get config from environment: %v\n", err)
 		os.Exit(1)
 	}
+	if *profile != "" {
+		if err := cfg.UseProfile(*profile); err != nil {
+			fmt.Printf("Failed to use profile %s: %v\n", *profile, err)
+			os.Exit(1)
+		}
+	}
+
+	c := cache.New(cacheMaxEntries, cacheMaxBytes)
+	var src source.Source
+	var aofLog *aof.Log
+	switch {
+	case *aofPath != "":
+		aofLog, err = aof.Load(*aofPath)
+		if err != nil {
+			fmt.Printf("Failed to load AOF %s - %v\n", *aofPath, err)
+			os.Exit(1)
+		}
+		var skipped []string
+		src, skipped = command.AOFSource(aofLog, len(aofLog.Commands))
+		log.Printf("Loaded %d commands from %s, skipped: %v", len(aofLog.Commands), *aofPath, skipped)
+
+	case *rdbPath != "":
+		f, err := rdb.Load(*rdbPath)
+		if err != nil {
+			fmt.Printf("Failed to load RDB file %s - %v\n", *rdbPath, err)
+			os.Exit(1)
+		}
+		src = source.NewRDB(f, *rdbPath)
+
+	default:
+		r := redis.NewClient(cfg.Option)
+		if _, err := r.Ping(ctx).Result(); err != nil {
+			fmt.Printf("Failed to connect to Redis at %s - %v\n", cfg.Option.Addr, err)
+			os.Exit(1)
+		}
+		if err := c.Track(ctx, cfg.Option); err != nil {
+			// Cached values are still invalidated on refresh, on our own writes and on expiry
+			log.Printf("Client tracking is not available: %v", err)
+		}
+		src = source.NewServer(r)
+	}
 
-	r := redis.NewClient(cfg.Option)
-	if _, err := r.Ping(ctx).Result(); err != nil {
-		fmt.Printf("Failed to connect to Redis at %s - %v\n", cfg.Option.Addr, err)
+	var extra []decoder.Decoder
+	if cfg.ProtoDescriptor != "" {
+		pb, err := decoder.NewProtobuf(cfg.ProtoDescriptor, cfg.ProtoMessage)
+		if err != nil {
+			fmt.Printf("Failed to load Protobuf descriptor: %v\n", err)
+			os.Exit(1)
+		}
+		extra = append(extra, pb)
+	}
+
+	cb, err := clipboard.Select(cfg.Clipboard, cfg.OSC52MaxBytes)
+	if err != nil {
+		fmt.Printf("Failed to select clipboard backend: %v\n", err)
 		os.Exit(1)
 	}
+	log.Printf("Using clipboard backend %s", cb.Name())
 
-	m := model.NewModel(ctx, r)
+	m := model.NewModel(ctx, src, c, decoder.NewPipeline(extra...), cb, cfg)
+	if aofLog != nil {
+		m = m.WithAOF(aofLog)
+	}
 
 	log.Println("Starting app now...")
 	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithoutBracketedPaste())
@@ -0,0 +1,330 @@
+// Package aof reads Redis append-only files, as a timeline of the commands they contain.
+package aof
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hirotake111/redisclient/internal/rdb"
+)
+
+// maxBulkLength is the default proto-max-bulk-len of Redis, the largest argument it
+// accepts, so that a corrupted length fails instead of exhausting memory.
+const maxBulkLength = 512 << 20
+
+// Command is a write command read from an append-only file.
+type Command struct {
+	Index  int       // 1-based position of the command in the timeline
+	File   string    // Name of the file the command was read from
+	Offset int64     // Byte offset of the command in the file
+	DB     int       // Database selected when the command ran
+	Time   time.Time // Time of the last timestamp annotation, zero if timestamps are disabled
+	Args   []string
+}
+
+// Name returns the upper-cased name of the command.
+func (c Command) Name() string {
+	if len(c.Args) == 0 {
+		return ""
+	}
+	return strings.ToUpper(c.Args[0])
+}
+
+// Keys returns the keys the command writes or reads.
+func (c Command) Keys() []string {
+	return keys(c.Args)
+}
+
+// Log is the content of an append-only file, or of the files listed in a manifest.
+type Log struct {
+	Path      string
+	Base      *rdb.File // Dataset the commands apply to, from an RDB preamble or base file, nil if empty
+	Commands  []Command
+	Truncated bool // Whether the last file ends with an incomplete command
+}
+
+// History returns the commands of db that involve key, including the commands
+// that affect every key of the database such as FLUSHDB.
+func (l *Log) History(db int, key string) []Command {
+	var cmds []Command
+	for _, c := range l.Commands {
+		if c.DB != db && !affectsAllDBs(c) {
+			continue
+		}
+		if affectsDB(c) {
+			cmds = append(cmds, c)
+			continue
+		}
+		for _, k := range c.Keys() {
+			if k == key {
+				cmds = append(cmds, c)
+				break
+			}
+		}
+	}
+	return cmds
+}
+
+// Load reads an append-only file, or a directory with the manifest of a multi-part AOF (Redis 7+).
+func Load(path string) (*Log, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{Path: path}
+	if !info.IsDir() {
+		if err := l.read(path); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+
+	files, err := manifest(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range files {
+		if l.Truncated {
+			return nil, fmt.Errorf("%s is truncated but isn't the last file", files[i-1])
+		}
+		if err := l.read(filepath.Join(path, f)); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// manifestFile is a file listed in a manifest.
+type manifestFile struct {
+	name string
+	seq  int
+	typ  string // b for base, i for incremental, h for history
+}
+
+// manifest returns the files of a multi-part AOF in the order they must be replayed:
+// the base file, then the incremental files. History files are obsolete and skipped.
+func manifest(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.manifest"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) != 1 {
+		return nil, fmt.Errorf("expected one manifest file in %s, found %d", dir, len(paths))
+	}
+	b, err := os.ReadFile(paths[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var files []manifestFile
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Lines are made of key value pairs, e.g. "file appendonly.aof.1.base.rdb seq 1 type b"
+		fields := strings.Fields(line)
+		if len(fields)%2 != 0 {
+			return nil, fmt.Errorf("invalid manifest line %d: %q", i+1, line)
+		}
+		var f manifestFile
+		for j := 0; j < len(fields); j += 2 {
+			switch fields[j] {
+			case "file":
+				f.name = strings.Trim(fields[j+1], `"`)
+			case "seq":
+				f.seq, err = strconv.Atoi(fields[j+1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid manifest line %d: %q", i+1, line)
+				}
+			case "type":
+				f.typ = fields[j+1]
+			}
+		}
+		if f.name == "" {
+			return nil, fmt.Errorf("invalid manifest line %d: %q", i+1, line)
+		}
+		if f.typ != "h" {
+			files = append(files, f)
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		if (files[i].typ == "b") != (files[j].typ == "b") {
+			return files[i].typ == "b"
+		}
+		return files[i].seq < files[j].seq
+	})
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.name)
+	}
+	return names, nil
+}
+
+// countingReader counts the bytes read, to report the offset of commands.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// read appends the commands of a file, which may start with an RDB preamble.
+func (l *Log) read(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	name := filepath.Base(path)
+	cr := &countingReader{r: bufio.NewReaderSize(f, 1<<16)}
+	br := bufio.NewReaderSize(cr, 1<<16)
+	// Offset of the next byte returned by br
+	offset := func() int64 { return cr.n - int64(br.Buffered()) }
+
+	if head, _ := br.Peek(5); string(head) == "REDIS" {
+		if l.Base != nil || len(l.Commands) > 0 {
+			return fmt.Errorf("%s: unexpected RDB data after the base file", name)
+		}
+		base, err := rdb.Parse(br)
+		if err != nil {
+			return fmt.Errorf("%s: failed to read RDB preamble: %w", name, err)
+		}
+		if base.Version >= 5 {
+			if _, err := br.Discard(8); err != nil { // Checksum
+				return fmt.Errorf("%s: failed to read RDB preamble: %w", name, err)
+			}
+		}
+		l.Base = base
+	}
+
+	db := 0
+	if n := len(l.Commands); n > 0 {
+		db = l.Commands[n-1].DB
+	}
+	var ts time.Time
+	for {
+		start := offset()
+		b, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch b {
+		case '#': // Annotation, e.g. a timestamp
+			line, err := readLine(br)
+			if err != nil {
+				l.Truncated = true
+				return nil
+			}
+			if s, ok := strings.CutPrefix(line, "TS:"); ok {
+				if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+					ts = time.Unix(sec, 0)
+				}
+			}
+
+		case '*':
+			args, err := readCommand(br)
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				// Redis can load such files with aof-load-truncated, ignoring the last command
+				log.Printf("%s is truncated at offset %d", name, start)
+				l.Truncated = true
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("%s: offset %d: %w", name, start, err)
+			}
+			if len(args) == 0 {
+				continue
+			}
+			if strings.EqualFold(args[0], "SELECT") && len(args) == 2 {
+				if n, err := strconv.Atoi(args[1]); err == nil {
+					db = n
+				}
+			}
+			l.Commands = append(l.Commands, Command{
+				Index:  len(l.Commands) + 1,
+				File:   name,
+				Offset: start,
+				DB:     db,
+				Time:   ts,
+				Args:   args,
+			})
+
+		default:
+			return fmt.Errorf("%s: offset %d: unexpected byte %q", name, start, b)
+		}
+	}
+}
+
+// readCommand reads the rest of a RESP array of bulk strings, after the leading '*'.
+func readCommand(br *bufio.Reader) ([]string, error) {
+	n, err := readInt(br)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, min(n, 1024))
+	for range n {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if b != '$' {
+			return nil, fmt.Errorf("expected a bulk string, got %q", b)
+		}
+		size, err := readInt(br)
+		if err != nil {
+			return nil, err
+		}
+		if size > maxBulkLength {
+			return nil, fmt.Errorf("bulk string length %d exceeds %d", size, maxBulkLength)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if string(buf[size:]) != "\r\n" {
+			return nil, errors.New("bulk string not terminated by CRLF")
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func readInt(br *bufio.Reader) (int, error) {
+	line, err := readLine(br)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(line)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid length %q", line)
+	}
+	return n, nil
+}
+
+// readLine reads a line terminated by CRLF, without the terminator.
+func readLine(br *bufio.Reader) (string, error) {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return "", io.ErrUnexpectedEOF
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
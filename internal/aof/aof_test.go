@@ -0,0 +1,143 @@
+package aof
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReadCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string // After the leading '*'
+		want  []string
+		isErr error // nil for any error when want is nil
+	}{
+		{name: "command", in: "3\r\n$3\r\nSET\r\n$1\r\nk\r\n$5\r\nhe\r\no\r\n", want: []string{"SET", "k", "he\r\no"}},
+		{name: "empty argument", in: "2\r\n$3\r\nGET\r\n$0\r\n\r\n", want: []string{"GET", ""}},
+		{name: "binary argument", in: "1\r\n$3\r\n\x00\xff\n\r\n", want: []string{"\x00\xff\n"}},
+		{name: "truncated count", in: "3", isErr: io.ErrUnexpectedEOF},
+		{name: "truncated arguments", in: "2\r\n$3\r\nGET\r\n", isErr: io.ErrUnexpectedEOF},
+		{name: "truncated argument", in: "1\r\n$5\r\nab", isErr: io.ErrUnexpectedEOF},
+		{name: "missing crlf", in: "1\r\n$3\r\nGETxx", want: nil},
+		{name: "not a bulk string", in: "1\r\n+OK\r\n", want: nil},
+		{name: "negative length", in: "1\r\n$-1\r\n", want: nil},
+		{name: "invalid count", in: "x\r\n", want: nil},
+		{name: "oversized length", in: "1\r\n$9999999999\r\nGET\r\n", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readCommand(bufio.NewReader(strings.NewReader(tt.in)))
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("readCommand = %q, want an error", got)
+				}
+				if tt.isErr != nil && !errors.Is(err, tt.isErr) {
+					t.Errorf("readCommand failed with %v, want %v", err, tt.isErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readCommand failed: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("readCommand = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// resp encodes a command as Redis writes it in append-only files.
+func resp(args ...string) string {
+	var sb strings.Builder
+	sb.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		sb.WriteString("$" + strconv.Itoa(len(a)) + "\r\n" + a + "\r\n")
+	}
+	return sb.String()
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestManifest(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"appendonly.aof.manifest": "file appendonly.aof.3.incr.aof seq 3 type i\n" +
+			"file appendonly.aof.1.base.aof seq 1 type h\n" +
+			"# comment\n\n" +
+			"file \"appendonly.aof.2.incr.aof\" seq 2 type i\n" +
+			"file appendonly.aof.2.base.rdb seq 2 type b\n" +
+			"file appendonly.aof.10.incr.aof seq 10 type i\n",
+	})
+	got, err := manifest(dir)
+	if err != nil {
+		t.Fatalf("manifest failed: %v", err)
+	}
+	want := []string{"appendonly.aof.2.base.rdb", "appendonly.aof.2.incr.aof", "appendonly.aof.3.incr.aof", "appendonly.aof.10.incr.aof"}
+	if !slices.Equal(got, want) {
+		t.Errorf("manifest = %q, want %q", got, want)
+	}
+}
+
+func TestManifestInvalid(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"no manifest":   {"appendonly.aof": ""},
+		"two manifests": {"a.manifest": "", "b.manifest": ""},
+		"odd fields":    {"a.manifest": "file a.aof seq\n"},
+		"invalid seq":   {"a.manifest": "file a.aof seq x type i\n"},
+		"no file name":  {"a.manifest": "seq 1 type i\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got, err := manifest(writeFiles(t, files)); err == nil {
+				t.Errorf("manifest = %q, want an error", got)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"appendonly.aof.manifest":   "file appendonly.aof.2.incr.aof seq 2 type i\nfile appendonly.aof.1.incr.aof seq 1 type i\n",
+		"appendonly.aof.1.incr.aof": resp("SET", "a", "1") + "#TS:1700000000\r\n" + resp("SELECT", "2") + resp("SET", "b", "2"),
+		// The database selected at the end of the previous file is still selected
+		"appendonly.aof.2.incr.aof": resp("DEL", "b") + resp("SET", "c", "3") + "*3\r\n$3\r\nSET\r\n",
+	})
+	l, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !l.Truncated {
+		t.Error("the incomplete last command isn't reported")
+	}
+	var got []string
+	for _, c := range l.Commands {
+		got = append(got, c.File+":"+strconv.Itoa(c.DB)+":"+strings.Join(c.Args, " "))
+	}
+	want := []string{
+		"appendonly.aof.1.incr.aof:0:SET a 1",
+		"appendonly.aof.1.incr.aof:2:SELECT 2",
+		"appendonly.aof.1.incr.aof:2:SET b 2",
+		"appendonly.aof.2.incr.aof:2:DEL b",
+		"appendonly.aof.2.incr.aof:2:SET c 3",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Load read\n%q\nwant\n%q", got, want)
+	}
+	if !l.Commands[0].Time.IsZero() || l.Commands[1].Time.Unix() != 1700000000 {
+		t.Errorf("timestamps are %v and %v", l.Commands[0].Time, l.Commands[1].Time)
+	}
+}
@@ -0,0 +1,116 @@
+package aof
+
+import (
+	"strconv"
+	"strings"
+)
+
+// keySpec gives the positions of the keys in the arguments of a command.
+type keySpec struct {
+	first int // Index of the first key, 0 if the command has no keys
+	last  int // Index of the last key, negative to count from the end
+	step  int
+}
+
+// keySpecs covers the commands that take other keys than their first argument,
+// or no key at all. Other commands are assumed to take a single key.
+var keySpecs = map[string]keySpec{
+	"SELECT":      {},
+	"MULTI":       {},
+	"EXEC":        {},
+	"DISCARD":     {},
+	"PING":        {},
+	"FLUSHDB":     {},
+	"FLUSHALL":    {},
+	"SWAPDB":      {},
+	"SCRIPT":      {},
+	"FUNCTION":    {},
+	"PUBLISH":     {},
+	"DEL":         {first: 1, last: -1, step: 1},
+	"UNLINK":      {first: 1, last: -1, step: 1},
+	"MSET":        {first: 1, last: -1, step: 2},
+	"MSETNX":      {first: 1, last: -1, step: 2},
+	"RENAME":      {first: 1, last: 2, step: 1},
+	"RENAMENX":    {first: 1, last: 2, step: 1},
+	"COPY":        {first: 1, last: 2, step: 1},
+	"SMOVE":       {first: 1, last: 2, step: 1},
+	"LMOVE":       {first: 1, last: 2, step: 1},
+	"RPOPLPUSH":   {first: 1, last: 2, step: 1},
+	"SINTERSTORE": {first: 1, last: -1, step: 1},
+	"SUNIONSTORE": {first: 1, last: -1, step: 1},
+	"SDIFFSTORE":  {first: 1, last: -1, step: 1},
+	"PFMERGE":     {first: 1, last: -1, step: 1},
+	"BITOP":       {first: 2, last: -1, step: 1},
+	"XGROUP":      {first: 2, last: 2, step: 1},
+	"XSETID":      {first: 1, last: 1, step: 1},
+}
+
+// keys returns the keys in the arguments of a command.
+func keys(args []string) []string {
+	if len(args) < 2 {
+		return nil
+	}
+	name := strings.ToUpper(args[0])
+	switch name {
+	case "ZUNIONSTORE", "ZINTERSTORE", "ZDIFFSTORE", "ZRANGESTORE", "GEOSEARCHSTORE":
+		// Destination, then the number of source keys
+		ks := []string{args[1]}
+		if name == "ZRANGESTORE" || name == "GEOSEARCHSTORE" {
+			return append(ks, args[2:min(3, len(args))]...)
+		}
+		return append(ks, numKeys(args[2:])...)
+
+	case "EVAL", "EVALSHA", "EVAL_RO", "EVALSHA_RO", "FCALL", "FCALL_RO":
+		// Script or function name, then the number of keys
+		return numKeys(args[2:])
+	}
+
+	spec, ok := keySpecs[name]
+	if !ok {
+		return args[1:2]
+	}
+	if spec.first == 0 || spec.first >= len(args) {
+		return nil
+	}
+	last := spec.last
+	if last < 0 {
+		last += len(args)
+	}
+	last = min(last, len(args)-1)
+	var ks []string
+	for i := spec.first; i <= last; i += spec.step {
+		ks = append(ks, args[i])
+	}
+	return ks
+}
+
+// numKeys returns the keys of arguments made of a number of keys followed by the keys,
+// and nil if the number is invalid.
+func numKeys(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		return nil
+	}
+	return args[1:min(1+n, len(args))]
+}
+
+// affectsDB reports whether the command affects every key of its database.
+func affectsDB(c Command) bool {
+	switch c.Name() {
+	case "FLUSHDB", "FLUSHALL", "SWAPDB":
+		return true
+	}
+	return false
+}
+
+// affectsAllDBs reports whether the command affects databases other than the selected one.
+func affectsAllDBs(c Command) bool {
+	switch c.Name() {
+	case "FLUSHALL", "SWAPDB":
+		return true
+	}
+	return false
+}
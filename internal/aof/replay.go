@@ -0,0 +1,649 @@
+package aof
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hirotake111/redisclient/internal/rdb"
+	"github.com/hirotake111/redisclient/internal/values"
+)
+
+// value is a key being rebuilt. Only the fields of its type are used.
+type value struct {
+	typ      string
+	str      string
+	fields   []string // Hash fields and set members in insertion order
+	hash     map[string]string
+	set      map[string]bool
+	list     []string
+	zset     map[string]float64
+	expireAt time.Time
+}
+
+// Replay rebuilds the dataset as it was after the first n commands, starting from the base.
+// It returns the keys by database, and the names of the commands that couldn't be replayed.
+func (l *Log) Replay(n int) (map[int]map[string]*rdb.Entry, []string) {
+	dbs := make(map[int]map[string]*value)
+	if l.Base != nil {
+		for db, entries := range l.Base.DBs {
+			dbs[db] = make(map[string]*value, len(entries))
+			for k, e := range entries {
+				dbs[db][k] = fromEntry(e)
+			}
+		}
+	}
+
+	skipped := make(map[string]bool)
+	for _, c := range l.Commands[:min(n, len(l.Commands))] {
+		if dbs[c.DB] == nil {
+			dbs[c.DB] = make(map[string]*value)
+		}
+		now := c.Time
+		if now.IsZero() {
+			now = time.Now()
+		}
+		if err := apply(dbs, c, now); err != nil {
+			skipped[c.Name()] = true
+		}
+	}
+
+	out := make(map[int]map[string]*rdb.Entry, len(dbs))
+	for db, keys := range dbs {
+		out[db] = make(map[string]*rdb.Entry, len(keys))
+		for k, v := range keys {
+			out[db][k] = v.entry()
+		}
+	}
+	return out, slices.Sorted(maps.Keys(skipped))
+}
+
+var errUnsupported = fmt.Errorf("unsupported command")
+
+// apply runs a write command. Commands are assumed to be valid, as Redis wrote them.
+func apply(dbs map[int]map[string]*value, c Command, now time.Time) error {
+	db := dbs[c.DB]
+	args := c.Args
+	name := c.Name()
+	// With or without SYNC or ASYNC
+	switch name {
+	case "FLUSHDB":
+		clear(db)
+		return nil
+	case "FLUSHALL":
+		clear(dbs)
+		dbs[c.DB] = make(map[string]*value)
+		return nil
+	}
+	if len(args) < 2 {
+		switch name {
+		case "MULTI", "EXEC", "DISCARD", "PING":
+			return nil
+		}
+		return errUnsupported
+	}
+	key := args[1]
+
+	// get returns the key if it exists with type t, creating it if create is true.
+	get := func(t string, create bool) (*value, error) {
+		v, ok := db[key]
+		if ok && v.typ != t {
+			return nil, fmt.Errorf("key %s is a %s, not a %s", key, v.typ, t)
+		}
+		if !ok && create {
+			v = &value{typ: t, hash: map[string]string{}, set: map[string]bool{}, zset: map[string]float64{}}
+			db[key] = v
+		}
+		return v, nil
+	}
+	// cleanup removes the key once its last element is removed.
+	cleanup := func(v *value) {
+		if v != nil && v.size() == 0 {
+			delete(db, key)
+		}
+	}
+
+	switch name {
+	case "SELECT", "SCRIPT", "FUNCTION", "PUBLISH":
+		return nil
+
+	case "SWAPDB":
+		a, err1 := strconv.Atoi(args[1])
+		b, err2 := strconv.Atoi(args[min(2, len(args)-1)])
+		if err1 != nil || err2 != nil {
+			return errUnsupported
+		}
+		dbs[a], dbs[b] = dbs[b], dbs[a]
+		for _, i := range []int{a, b} {
+			if dbs[i] == nil {
+				dbs[i] = make(map[string]*value)
+			}
+		}
+
+	case "SET", "SETNX", "GETSET", "SETEX", "PSETEX":
+		switch name {
+		case "SETEX", "PSETEX":
+			if len(args) < 4 {
+				return errUnsupported
+			}
+			unit := "EX"
+			if name == "PSETEX" {
+				unit = "PX"
+			}
+			args = []string{"SET", key, args[3], unit, args[2]}
+		case "SETNX":
+			args = append(args, "NX")
+		}
+		if len(args) < 3 {
+			return errUnsupported
+		}
+		return set(db, key, args[2], args[3:], now)
+
+	case "MSET", "MSETNX":
+		if name == "MSETNX" {
+			for i := 1; i < len(args); i += 2 {
+				if _, ok := db[args[i]]; ok {
+					return nil
+				}
+			}
+		}
+		for i := 1; i+1 < len(args); i += 2 {
+			db[args[i]] = &value{typ: "string", str: args[i+1]}
+		}
+
+	case "APPEND":
+		if len(args) < 3 {
+			return errUnsupported
+		}
+		v, err := get("string", true)
+		if err != nil {
+			return err
+		}
+		v.str += args[2]
+
+	case "INCR", "DECR", "INCRBY", "DECRBY", "INCRBYFLOAT":
+		v, err := get("string", true)
+		if err != nil {
+			return err
+		}
+		return incr(v, name, args)
+
+	case "SETRANGE":
+		if len(args) < 4 {
+			return errUnsupported
+		}
+		offset, err := strconv.Atoi(args[2])
+		if err != nil || offset < 0 {
+			return errUnsupported
+		}
+		v, err := get("string", true)
+		if err != nil {
+			return err
+		}
+		b := []byte(v.str)
+		if end := offset + len(args[3]); end > len(b) {
+			b = append(b, make([]byte, end-len(b))...)
+		}
+		copy(b[offset:], args[3])
+		v.str = string(b)
+
+	case "DEL", "UNLINK", "GETDEL":
+		for _, k := range keys(args) {
+			delete(db, k)
+		}
+
+	case "EXPIRE", "PEXPIRE", "EXPIREAT", "PEXPIREAT", "PERSIST", "GETEX":
+		v, ok := db[key]
+		if !ok {
+			return nil
+		}
+		return expire(v, name, args, now)
+
+	case "RENAME", "RENAMENX":
+		v, ok := db[key]
+		if !ok || len(args) < 3 {
+			return nil
+		}
+		if _, exists := db[args[2]]; exists && name == "RENAMENX" {
+			return nil
+		}
+		delete(db, key)
+		db[args[2]] = v
+
+	case "HSET", "HMSET", "HSETNX":
+		v, err := get("hash", true)
+		if err != nil {
+			return err
+		}
+		for i := 2; i+1 < len(args); i += 2 {
+			if _, ok := v.hash[args[i]]; ok && name == "HSETNX" {
+				continue
+			}
+			v.hset(args[i], args[i+1])
+		}
+
+	case "HDEL":
+		v, err := get("hash", false)
+		if err != nil || v == nil {
+			return err
+		}
+		for _, f := range args[2:] {
+			delete(v.hash, f)
+		}
+		v.fields = slices.DeleteFunc(v.fields, func(f string) bool {
+			_, ok := v.hash[f]
+			return !ok
+		})
+		cleanup(v)
+
+	case "HINCRBY", "HINCRBYFLOAT":
+		v, err := get("hash", true)
+		if err != nil {
+			return err
+		}
+		if len(args) < 4 {
+			return errUnsupported
+		}
+		sum, err := add(orDefault(v.hash[args[2]], "0"), args[3], false, name == "HINCRBYFLOAT")
+		if err != nil {
+			return err
+		}
+		v.hset(args[2], sum)
+
+	case "LPUSH", "RPUSH", "LPUSHX", "RPUSHX":
+		v, err := get("list", !strings.HasSuffix(name, "X"))
+		if err != nil || v == nil {
+			return err
+		}
+		for _, e := range args[2:] {
+			if name[0] == 'L' {
+				v.list = append([]string{e}, v.list...)
+			} else {
+				v.list = append(v.list, e)
+			}
+		}
+
+	case "LPOP", "RPOP":
+		v, err := get("list", false)
+		if err != nil || v == nil {
+			return err
+		}
+		count := 1
+		if len(args) > 2 {
+			if count, err = strconv.Atoi(args[2]); err != nil {
+				return err
+			}
+		}
+		count = min(count, len(v.list))
+		if name == "LPOP" {
+			v.list = v.list[count:]
+		} else {
+			v.list = v.list[:len(v.list)-count]
+		}
+		cleanup(v)
+
+	case "LSET":
+		v, err := get("list", false)
+		if err != nil || v == nil || len(args) < 4 {
+			return err
+		}
+		i, err := strconv.Atoi(args[2])
+		if err != nil {
+			return err
+		}
+		if i < 0 {
+			i += len(v.list)
+		}
+		if i >= 0 && i < len(v.list) {
+			v.list[i] = args[3]
+		}
+
+	case "LTRIM":
+		v, err := get("list", false)
+		if err != nil || v == nil || len(args) < 4 {
+			return err
+		}
+		start, err1 := strconv.Atoi(args[2])
+		stop, err2 := strconv.Atoi(args[3])
+		if err1 != nil || err2 != nil {
+			return errUnsupported
+		}
+		start, stop = listRange(start, stop, len(v.list))
+		if start > stop {
+			v.list = nil
+		} else {
+			v.list = v.list[start : stop+1]
+		}
+		cleanup(v)
+
+	case "SADD":
+		v, err := get("set", true)
+		if err != nil {
+			return err
+		}
+		for _, m := range args[2:] {
+			if !v.set[m] {
+				v.set[m] = true
+				v.fields = append(v.fields, m)
+			}
+		}
+
+	case "SREM":
+		// SPOP is propagated as SREM too
+		v, err := get("set", false)
+		if err != nil || v == nil {
+			return err
+		}
+		for _, m := range args[2:] {
+			delete(v.set, m)
+		}
+		v.fields = slices.DeleteFunc(v.fields, func(m string) bool { return !v.set[m] })
+		cleanup(v)
+
+	case "ZADD", "ZINCRBY":
+		v, err := get("zset", true)
+		if err != nil {
+			return err
+		}
+		return zadd(v, name, args[2:])
+
+	case "ZREM":
+		v, err := get("zset", false)
+		if err != nil || v == nil {
+			return err
+		}
+		for _, m := range args[2:] {
+			delete(v.zset, m)
+		}
+		cleanup(v)
+
+	case "XADD", "XDEL", "XTRIM", "XSETID", "XGROUP", "XACK", "XCLAIM", "XAUTOCLAIM":
+		// Stream entries can't be displayed, only the key is tracked
+		if name == "XADD" {
+			if _, err := get("stream", true); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return errUnsupported
+	}
+	return nil
+}
+
+// set runs SET with its options.
+func set(db map[string]*value, key, val string, opts []string, now time.Time) error {
+	old, exists := db[key]
+	v := &value{typ: "string", str: val}
+	for i := 0; i < len(opts); i++ {
+		switch strings.ToUpper(opts[i]) {
+		case "NX":
+			if exists {
+				return nil
+			}
+		case "XX":
+			if !exists {
+				return nil
+			}
+		case "KEEPTTL":
+			if exists {
+				v.expireAt = old.expireAt
+			}
+		case "EX", "PX", "EXAT", "PXAT":
+			if i+1 >= len(opts) {
+				return errUnsupported
+			}
+			if err := v.setExpire(strings.ToUpper(opts[i]), opts[i+1], now); err != nil {
+				return err
+			}
+			i++
+		}
+	}
+	db[key] = v
+	return nil
+}
+
+func expire(v *value, name string, args []string, now time.Time) error {
+	switch name {
+	case "PERSIST":
+		v.expireAt = time.Time{}
+		return nil
+	case "GETEX":
+		if len(args) < 3 {
+			return nil
+		}
+		if strings.EqualFold(args[2], "PERSIST") {
+			v.expireAt = time.Time{}
+			return nil
+		}
+		if len(args) < 4 {
+			return errUnsupported
+		}
+		return v.setExpire(strings.ToUpper(args[2]), args[3], now)
+	}
+	if len(args) < 3 {
+		return errUnsupported
+	}
+	unit := map[string]string{"EXPIRE": "EX", "PEXPIRE": "PX", "EXPIREAT": "EXAT", "PEXPIREAT": "PXAT"}[name]
+	return v.setExpire(unit, args[2], now)
+}
+
+// setExpire sets the expiration time from a SET option, e.g. EX 10.
+func (v *value) setExpire(unit, arg string, now time.Time) error {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return err
+	}
+	switch unit {
+	case "EX":
+		v.expireAt = now.Add(time.Duration(n) * time.Second)
+	case "PX":
+		v.expireAt = now.Add(time.Duration(n) * time.Millisecond)
+	case "EXAT":
+		v.expireAt = time.Unix(n, 0)
+	case "PXAT":
+		v.expireAt = time.UnixMilli(n)
+	default:
+		return errUnsupported
+	}
+	return nil
+}
+
+func incr(v *value, name string, args []string) error {
+	inc := "1"
+	if len(args) > 2 {
+		inc = args[2]
+	}
+	sum, err := add(orDefault(v.str, "0"), inc, strings.HasPrefix(name, "DECR"), name == "INCRBYFLOAT")
+	if err != nil {
+		return err
+	}
+	v.str = sum
+	return nil
+}
+
+// add adds the increment to the number, or subtracts it, as 64-bit integers like INCRBY
+// and HINCRBY, or as floats like INCRBYFLOAT and HINCRBYFLOAT.
+func add(number, inc string, subtract, float bool) (string, error) {
+	if float {
+		a, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return "", err
+		}
+		b, err := strconv.ParseFloat(inc, 64)
+		if err != nil {
+			return "", err
+		}
+		if subtract {
+			b = -b
+		}
+		return strconv.FormatFloat(a+b, 'f', -1, 64), nil
+	}
+	a, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return "", err
+	}
+	b, err := strconv.ParseInt(inc, 10, 64)
+	if err != nil {
+		return "", err
+	}
+	if subtract {
+		if b == math.MinInt64 {
+			return "", errors.New("decrement would overflow")
+		}
+		b = -b
+	}
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return "", errors.New("increment or decrement would overflow")
+	}
+	return strconv.FormatInt(a+b, 10), nil
+}
+
+// zadd runs ZADD or ZINCRBY with the arguments following the key.
+func zadd(v *value, name string, args []string) error {
+	var nx, xx, gt, lt, incr bool
+	incr = name == "ZINCRBY"
+	i := 0
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GT":
+			gt = true
+		case "LT":
+			lt = true
+		case "INCR":
+			incr = true
+		case "CH":
+		default:
+			goto scores
+		}
+	}
+scores:
+	for ; i+1 < len(args); i += 2 {
+		score, err := parseScore(args[i])
+		if err != nil {
+			return err
+		}
+		m := args[i+1]
+		old, exists := v.zset[m]
+		if (nx && exists) || (xx && !exists) {
+			continue
+		}
+		if incr {
+			score += old
+		}
+		if exists && ((gt && score <= old) || (lt && score >= old)) {
+			continue
+		}
+		v.zset[m] = score
+	}
+	return nil
+}
+
+func parseScore(s string) (float64, error) {
+	switch strings.ToLower(s) {
+	case "+inf", "inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// listRange converts LTRIM/LRANGE indexes, which may be negative, to a valid range.
+func listRange(start, stop, n int) (int, int) {
+	if start < 0 {
+		start = max(start+n, 0)
+	}
+	if stop < 0 {
+		stop += n
+	}
+	stop = min(stop, n-1)
+	return start, stop
+}
+
+// orDefault returns s, or def if s is empty.
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+func (v *value) hset(field, val string) {
+	if _, ok := v.hash[field]; !ok {
+		v.fields = append(v.fields, field)
+	}
+	v.hash[field] = val
+}
+
+func (v *value) size() int {
+	switch v.typ {
+	case "hash":
+		return len(v.hash)
+	case "set":
+		return len(v.set)
+	case "list":
+		return len(v.list)
+	case "zset":
+		return len(v.zset)
+	}
+	return 1
+}
+
+func fromEntry(e *rdb.Entry) *value {
+	v := &value{typ: e.Type, str: e.Value, expireAt: e.ExpireAt, hash: map[string]string{}, set: map[string]bool{}, zset: map[string]float64{}}
+	for _, el := range e.Elements {
+		switch e.Type {
+		case "hash":
+			v.hset(el.Field, el.Value)
+		case "set":
+			v.set[el.Field] = true
+			v.fields = append(v.fields, el.Field)
+		case "list":
+			v.list = append(v.list, el.Field)
+		case "zset":
+			v.zset[el.Field] = el.Score
+		}
+	}
+	return v
+}
+
+func (v *value) entry() *rdb.Entry {
+	e := &rdb.Entry{Type: v.typ, Value: v.str, ExpireAt: v.expireAt, Idle: -1, Freq: -1}
+	switch v.typ {
+	case "hash":
+		for _, f := range v.fields {
+			e.Elements = append(e.Elements, values.Element{Field: f, Value: v.hash[f]})
+		}
+	case "set":
+		for _, m := range v.fields {
+			e.Elements = append(e.Elements, values.Element{Field: m})
+		}
+	case "list":
+		for _, it := range v.list {
+			e.Elements = append(e.Elements, values.Element{Field: it})
+		}
+	case "zset":
+		for m, s := range v.zset {
+			e.Elements = append(e.Elements, values.Element{Field: m, Score: s})
+		}
+		sort.Slice(e.Elements, func(i, j int) bool {
+			a, b := e.Elements[i], e.Elements[j]
+			if a.Score != b.Score {
+				return a.Score < b.Score
+			}
+			return a.Field < b.Field
+		})
+	}
+	return e
+}
@@ -0,0 +1,137 @@
+package aof
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hirotake111/redisclient/internal/rdb"
+)
+
+// commands returns a log of commands, each made of space-separated arguments,
+// selecting databases with SELECT as Redis writes it.
+func commands(lines ...string) *Log {
+	l := &Log{}
+	db := 0
+	for _, line := range lines {
+		args := strings.Fields(line)
+		if strings.EqualFold(args[0], "SELECT") {
+			db = int(args[1][0] - '0')
+		}
+		l.Commands = append(l.Commands, Command{Index: len(l.Commands) + 1, DB: db, Args: args})
+	}
+	return l
+}
+
+// elements returns the fields of the elements of an entry.
+func elements(e *rdb.Entry) []string {
+	var fields []string
+	for _, el := range e.Elements {
+		fields = append(fields, el.Field)
+	}
+	return fields
+}
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name    string
+		log     *Log
+		want    map[int]map[string]string // Values of strings, or elements of lists joined by commas
+		skipped []string
+	}{
+		{
+			name: "flushdb",
+			log:  commands("SET a 1", "SELECT 1", "SET b 2", "FLUSHDB ASYNC", "SET c 3"),
+			want: map[int]map[string]string{0: {"a": "1"}, 1: {"c": "3"}},
+		},
+		{
+			name: "flushall",
+			log:  commands("SET a 1", "SELECT 1", "SET b 2", "FLUSHALL SYNC", "SET c 3"),
+			want: map[int]map[string]string{1: {"c": "3"}},
+		},
+		{
+			name: "flushall without argument",
+			log:  commands("SET a 1", "FLUSHALL", "SET b 2"),
+			want: map[int]map[string]string{0: {"b": "2"}},
+		},
+		{
+			name: "select",
+			log:  commands("SET a 1", "SELECT 3", "SET a 2", "SELECT 0", "APPEND a x"),
+			want: map[int]map[string]string{0: {"a": "1x"}, 3: {"a": "2"}},
+		},
+		{
+			name: "large integers",
+			log:  commands("SET id 9007199254740993", "INCR id", "INCRBY n 9007199254740993", "DECRBY n 2"),
+			want: map[int]map[string]string{0: {"id": "9007199254740994", "n": "9007199254740991"}},
+		},
+		{
+			name:    "overflow",
+			log:     commands("SET n 9223372036854775807", "INCR n", "SET m -9223372036854775808", "DECR m"),
+			want:    map[int]map[string]string{0: {"n": "9223372036854775807", "m": "-9223372036854775808"}},
+			skipped: []string{"DECR", "INCR"},
+		},
+		{
+			name: "float increments",
+			log:  commands("SET f 1.5", "INCRBYFLOAT f 0.25", "INCRBY i -3"),
+			want: map[int]map[string]string{0: {"f": "1.75", "i": "-3"}},
+		},
+		{
+			name: "push order",
+			log:  commands("LPUSH l a b c", "RPUSH l d e", "LPUSHX missing x", "RPUSHX l f"),
+			want: map[int]map[string]string{0: {"l": "c,b,a,d,e,f"}},
+		},
+		{
+			name:    "missing arguments",
+			log:     commands("APPEND a", "SETRANGE a 1", "SETRANGE a -1 x", "SET b 1"),
+			want:    map[int]map[string]string{0: {"b": "1"}},
+			skipped: []string{"APPEND", "SETRANGE"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbs, skipped := tt.log.Replay(len(tt.log.Commands))
+			got := make(map[int]map[string]string)
+			for db, entries := range dbs {
+				for k, e := range entries {
+					if got[db] == nil {
+						got[db] = make(map[string]string)
+					}
+					v := e.Value
+					if e.Type != "string" {
+						v = strings.Join(elements(e), ",")
+					}
+					got[db][k] = v
+				}
+			}
+			for db, keys := range tt.want {
+				for k, want := range keys {
+					if got[db][k] != want {
+						t.Errorf("db %d key %s = %q, want %q", db, k, got[db][k], want)
+					}
+				}
+				if len(got[db]) != len(keys) {
+					t.Errorf("db %d has keys %v, want %v", db, got[db], keys)
+				}
+			}
+			for db, keys := range got {
+				if len(keys) > 0 && tt.want[db] == nil {
+					t.Errorf("db %d has keys %v, want none", db, keys)
+				}
+			}
+			if !slices.Equal(skipped, tt.skipped) {
+				t.Errorf("skipped %v, want %v", skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestReplayHashIncrement(t *testing.T) {
+	dbs, _ := commands("HINCRBY h f 9007199254740993", "HINCRBY h f 1", "HINCRBYFLOAT h g 0.5").Replay(3)
+	got := map[string]string{}
+	for _, el := range dbs[0]["h"].Elements {
+		got[el.Field] = el.Value
+	}
+	if got["f"] != "9007199254740994" || got["g"] != "0.5" {
+		t.Errorf("HINCRBY and HINCRBYFLOAT gave %v", got)
+	}
+}
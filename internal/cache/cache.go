@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hirotake111/redisclient/internal/values"
+)
+
+// Entry is a cached value along with the metadata needed to display it.
+type Entry struct {
+	Value string // Rendered value data
+	Type  string // Redis type of the key
+	// First page of elements for hashes, lists, sets and sorted sets
+	Collection *values.Collection
+	TTL        int64     // TTL in seconds at the time of fetching, <= 0 if the key doesn't expire
+	FetchedAt  time.Time // Time at which the value was fetched from Redis
+}
+
+// RemainingTTL returns the TTL of the entry as of now.
+func (e Entry) RemainingTTL(now time.Time) int64 {
+	if e.TTL <= 0 {
+		return e.TTL
+	}
+	return e.TTL - int64(now.Sub(e.FetchedAt).Seconds())
+}
+
+// Expired reports whether the key has expired in Redis since the entry was fetched.
+func (e Entry) Expired(now time.Time) bool {
+	return e.TTL > 0 && e.RemainingTTL(now) <= 0
+}
+
+type element struct {
+	db    int
+	key   string
+	entry Entry
+}
+
+// ValueCache is an LRU cache of values keyed by database and key.
+// It is safe for concurrent use, as commands run in their own goroutines.
+type ValueCache struct {
+	mu         sync.Mutex
+	ll         *list.List
+	items      map[string]*list.Element
+	maxEntries int // Maximum number of entries, 0 means no limit
+	maxBytes   int // Maximum total size of cached values, 0 means no limit
+	bytes      int
+}
+
+func New(maxEntries, maxBytes int) *ValueCache {
+	return &ValueCache{
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
+func cacheKey(db int, key string) string {
+	return fmt.Sprintf("%d:%s", db, key)
+}
+
+// Get returns the cached entry for the key, if it exists and has not expired.
+func (c *ValueCache) Get(db int, key string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[cacheKey(db, key)]
+	if !ok {
+		return Entry{}, false
+	}
+	e := el.Value.(*element)
+	if e.entry.Expired(time.Now()) {
+		log.Printf("Cached value for key \"%s\" (DB: %d) has expired", key, db)
+		c.remove(el)
+		return Entry{}, false
+	}
+	c.ll.MoveToFront(el)
+	return e.entry, true
+}
+
+// Put adds or replaces the entry for the key, evicting the least recently used entries if needed.
+func (c *ValueCache) Put(db int, key string, entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxBytes > 0 && len(entry.Value) > c.maxBytes {
+		log.Printf("Value for key \"%s\" is too large to cache (%d bytes)", key, len(entry.Value))
+		return
+	}
+
+	ck := cacheKey(db, key)
+	if el, ok := c.items[ck]; ok {
+		c.remove(el)
+	}
+	c.items[ck] = c.ll.PushFront(&element{db: db, key: key, entry: entry})
+	c.bytes += len(entry.Value)
+
+	for (c.maxEntries > 0 && c.ll.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.remove(c.ll.Back())
+	}
+}
+
+// Invalidate removes the entry for the key in the given database.
+func (c *ValueCache) Invalidate(db int, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[cacheKey(db, key)]; ok {
+		c.remove(el)
+	}
+}
+
+// InvalidateKey removes the entries for the key in every database.
+func (c *ValueCache) InvalidateKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for el := c.ll.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*element).key == key {
+			c.remove(el)
+		}
+		el = next
+	}
+}
+
+// Purge removes every entry from the cache.
+func (c *ValueCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	log.Printf("Purging %d cached values", c.ll.Len())
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+}
+
+func (c *ValueCache) remove(el *list.Element) {
+	e := c.ll.Remove(el).(*element)
+	delete(c.items, cacheKey(e.db, e.key))
+	c.bytes -= len(e.entry.Value)
+}
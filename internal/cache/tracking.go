@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const invalidationChannel = "__redis__:invalidate"
+
+// Backoff between attempts to enable tracking again after the connections dropped.
+const (
+	minRetryDelay = time.Second
+	maxRetryDelay = 30 * time.Second
+)
+
+// Track enables server-assisted invalidation (CLIENT TRACKING, Redis 6+) and
+// removes keys from the cache as soon as they are modified by anyone.
+//
+// go-redis doesn't surface RESP3 push messages, so tracking runs in broadcasting
+// mode on a dedicated connection and redirects invalidations to a Pub/Sub
+// connection subscribed to the invalidation channel. It is enabled again when the
+// connections drop, e.g. on a server restart. Tracking stops when ctx is cancelled.
+func (c *ValueCache) Track(ctx context.Context, opt *redis.Options) error {
+	name := "red-invalidation-" + uuid.NewString()
+
+	subOpt := *opt
+	subOpt.ClientName = name
+	sub := redis.NewClient(&subOpt)
+	ps := sub.Subscribe(ctx, invalidationChannel)
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return fmt.Errorf("failed to subscribe to invalidation channel: %w", err)
+	}
+
+	trackerOpt := *opt
+	inv := &invalidator{name: name, sub: sub, ps: ps, tracker: redis.NewClient(&trackerOpt)}
+	if err := inv.redirect(ctx); err != nil {
+		inv.close()
+		return err
+	}
+
+	go func() {
+		defer inv.close()
+		for {
+			msg, err := ps.ReceiveMessage(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				// FLUSHALL and FLUSHDB send a null payload, which go-redis fails to parse.
+				// Purge the whole cache to be on the safe side.
+				log.Printf("Error receiving invalidation message: %v", err)
+				c.Purge()
+				// The Pub/Sub connection may have been replaced by a new one with another ID,
+				// and the tracking connection closed, e.g. by a server restart
+				c.retrack(ctx, inv)
+				continue
+			}
+			for _, key := range msg.PayloadSlice {
+				log.Printf("Invalidating cached value for key \"%s\"", key)
+				c.InvalidateKey(key)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// retrack enables tracking again on a new connection, backing off until it succeeds.
+// The cache is purged after every failure, as invalidations may be lost meanwhile.
+func (c *ValueCache) retrack(ctx context.Context, inv *invalidator) {
+	delay := minRetryDelay
+	for {
+		err := inv.redirect(ctx)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		log.Printf("Failed to enable client tracking again, retrying in %s: %v", delay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		c.Purge()
+		delay = min(2*delay, maxRetryDelay)
+	}
+}
+
+// invalidator holds the connections invalidations go through.
+type invalidator struct {
+	name    string // Client name of the Pub/Sub connection
+	sub     *redis.Client
+	ps      *redis.PubSub
+	tracker *redis.Client
+	// The connection that has tracking enabled must stay open for invalidations to keep coming
+	conn *redis.Conn
+}
+
+// redirect enables tracking on a new connection, redirecting invalidations to the
+// current Pub/Sub connection, and closes the previous tracking connection.
+func (inv *invalidator) redirect(ctx context.Context) error {
+	// Reconnects and subscribes again if the Pub/Sub connection dropped
+	if err := inv.ps.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to reach the invalidation connection: %w", err)
+	}
+	id, err := clientIDByName(ctx, inv.sub, inv.name)
+	if err != nil {
+		return err
+	}
+	conn := inv.tracker.Conn()
+	if err := conn.Do(ctx, "CLIENT", "TRACKING", "ON", "REDIRECT", id, "BCAST").Err(); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to enable client tracking: %w", err)
+	}
+	if inv.conn != nil {
+		_ = inv.conn.Close()
+	}
+	inv.conn = conn
+	log.Printf("Client tracking enabled, redirecting invalidations to client %d", id)
+	return nil
+}
+
+func (inv *invalidator) close() {
+	if inv.conn != nil {
+		_ = inv.conn.Close()
+	}
+	_ = inv.tracker.Close()
+	_ = inv.ps.Close()
+	_ = inv.sub.Close()
+}
+
+// clientIDByName looks up the ID of the subscribed connection with the given client name.
+func clientIDByName(ctx context.Context, client *redis.Client, name string) (int64, error) {
+	infos, err := client.ClientList(ctx).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list clients: %w", err)
+	}
+	for _, line := range strings.Split(infos, "\n") {
+		fields := make(map[string]string)
+		for _, f := range strings.Fields(line) {
+			if k, v, ok := strings.Cut(f, "="); ok {
+				fields[k] = v
+			}
+		}
+		if fields["name"] != name || fields["sub"] == "0" {
+			continue
+		}
+		if id, err := strconv.ParseInt(fields["id"], 10, 64); err == nil {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("failed to find the invalidation connection")
+}
@@ -0,0 +1,247 @@
+// Package chart draws line charts in the terminal with braille characters.
+package chart
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hirotake111/redisclient/internal/color"
+)
+
+// Point is a sample of a series, at a time in milliseconds.
+type Point struct {
+	T int64
+	V float64
+}
+
+// Series is a line of a chart.
+type Series struct {
+	Name   string
+	Points []Point // Sorted by time
+}
+
+// Each cell holds 2x4 dots, whose bits are offset from the empty braille pattern.
+const (
+	brailleBase = 0x2800
+	dotsX       = 2
+	dotsY       = 4
+)
+
+// dotBits maps the column and row of a dot in a cell to its bit.
+var dotBits = [dotsX][dotsY]rune{{0x01, 0x02, 0x04, 0x40}, {0x08, 0x10, 0x20, 0x80}}
+
+// palette colors the series in turn.
+var palette = []lipgloss.Color{color.Primary, color.Warning, color.JSONString, color.JSONNumber, color.Error, color.Grey}
+
+var axisStyle = lipgloss.NewStyle().Foreground(color.Grey)
+
+// Line draws the series in width x height cells, axes and legend included. Series are
+// scaled to the same time and value ranges, and colored in turn; where they cross,
+// the last one is visible. Infinite and NaN samples are skipped.
+func Line(series []Series, width, height int) string {
+	series = finiteSeries(series)
+	t0, t1 := int64(math.MaxInt64), int64(math.MinInt64)
+	v0, v1 := math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		for _, p := range s.Points {
+			t0, t1 = min(t0, p.T), max(t1, p.T)
+			v0, v1 = math.Min(v0, p.V), math.Max(v1, p.V)
+		}
+	}
+	if t0 > t1 {
+		return axisStyle.Render("No samples in this time range.")
+	}
+	if v0 == v1 {
+		// A flat line is drawn in the middle
+		v0, v1 = v0-1, v1+1
+	}
+
+	labels := [3]string{formatValue(v1), formatValue((v0 + v1) / 2), formatValue(v0)}
+	c := newCanvas(width, height, labels)
+	scaleX := func(t int64) int {
+		if t1 == t0 {
+			return c.xDots / 2
+		}
+		return int(math.Round(float64(t-t0) / float64(t1-t0) * float64(c.xDots-1)))
+	}
+	scaleY := func(v float64) int {
+		// Dots are counted from the top
+		return c.yDots - 1 - scale(v, v0, v1, c.yDots)
+	}
+	for i, s := range series {
+		for j, p := range s.Points {
+			x, y := scaleX(p.T), scaleY(p.V)
+			if j == 0 {
+				c.dot(x, y, i)
+				continue
+			}
+			prev := s.Points[j-1]
+			segment(scaleX(prev.T), scaleY(prev.V), x, y, func(x, y int) { c.dot(x, y, i) })
+		}
+	}
+
+	names := make([]string, len(series))
+	for i, s := range series {
+		names[i] = s.Name
+	}
+	// Time axis, with the first and last times at both ends
+	return c.render(labels, formatTime(t0, t1-t0), formatTime(t1, t1-t0), names)
+}
+
+// finiteSeries returns the series without their infinite and NaN samples, which can't be scaled.
+func finiteSeries(series []Series) []Series {
+	out := make([]Series, len(series))
+	for i, s := range series {
+		out[i] = Series{Name: s.Name, Points: make([]Point, 0, len(s.Points))}
+		for _, p := range s.Points {
+			if finite(p.V) {
+				out[i].Points = append(out[i].Points, p)
+			}
+		}
+	}
+	return out
+}
+
+// scale returns the dot of v among n dots spanning lo to hi. Ranges wider than the float64
+// range scale to NaN, drawn on the first dot rather than overflowing.
+func scale(v, lo, hi float64, n int) int {
+	f := math.Round((v - lo) / (hi - lo) * float64(n-1))
+	if math.IsNaN(f) {
+		return 0
+	}
+	return int(math.Max(0, math.Min(f, float64(n-1))))
+}
+
+func finite(f float64) bool {
+	return !math.IsInf(f, 0) && !math.IsNaN(f)
+}
+
+// canvas is a grid of braille cells, with room for the labels of the y axis on the left
+// and for the x axis and the legend below.
+type canvas struct {
+	cells      [][]rune
+	owners     [][]int // Index of the series that last drew a dot in each cell
+	labelWidth int
+	xDots      int
+	yDots      int
+}
+
+func newCanvas(width, height int, labels [3]string) canvas {
+	c := canvas{}
+	for _, l := range labels {
+		c.labelWidth = max(c.labelWidth, len(l))
+	}
+	cols := max(1, width-c.labelWidth-2)
+	rows := max(2, height-3) // The x axis and the legend take the other lines
+	c.cells = make([][]rune, rows)
+	c.owners = make([][]int, rows)
+	for r := range c.cells {
+		c.cells[r] = make([]rune, cols)
+		c.owners[r] = make([]int, cols)
+	}
+	c.xDots, c.yDots = cols*dotsX, rows*dotsY
+	return c
+}
+
+// dot draws the dot at (x, y), counted from the top left, for a series.
+func (c canvas) dot(x, y, owner int) {
+	if x < 0 || x >= c.xDots || y < 0 || y >= c.yDots {
+		return
+	}
+	r, col := y/dotsY, x/dotsX
+	c.cells[r][col] |= dotBits[x%dotsX][y%dotsY]
+	c.owners[r][col] = owner
+}
+
+// render renders the cells with the labels of the top, middle and bottom of the y axis,
+// the labels of both ends of the x axis, and the names of the series as a legend.
+func (c canvas) render(labels [3]string, start, end string, names []string) string {
+	var sb strings.Builder
+	rows := len(c.cells)
+	for r := range c.cells {
+		label := ""
+		switch r {
+		case 0:
+			label = labels[0]
+		case rows / 2:
+			label = labels[1]
+		case rows - 1:
+			label = labels[2]
+		}
+		sb.WriteString(axisStyle.Render(strings.Repeat(" ", c.labelWidth-len(label)) + label + " ┤"))
+		for col, bits := range c.cells[r] {
+			if bits == 0 {
+				sb.WriteByte(' ')
+				continue
+			}
+			style := lipgloss.NewStyle().Foreground(palette[c.owners[r][col]%len(palette)])
+			sb.WriteString(style.Render(string(brailleBase + bits)))
+		}
+		sb.WriteByte('\n')
+	}
+
+	gap := max(1, len(c.cells[0])-len(start)-len(end))
+	sb.WriteString(axisStyle.Render(strings.Repeat(" ", c.labelWidth+2) + start + strings.Repeat(" ", gap) + end))
+	sb.WriteByte('\n')
+
+	legend := make([]string, len(names))
+	for i, name := range names {
+		style := lipgloss.NewStyle().Foreground(palette[i%len(palette)])
+		legend[i] = style.Render("⣿ " + name)
+	}
+	sb.WriteString(strings.Repeat(" ", c.labelWidth+2) + strings.Join(legend, "  "))
+	return sb.String()
+}
+
+// segment calls plot for each dot of the line from (x0, y0) to (x1, y1), with Bresenham's algorithm.
+func segment(x0, y0, x1, y1 int, plot func(x, y int)) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		plot(x0, y0)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * err; e2 >= dy {
+			err += dy
+			x0 += sx
+		} else {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+// formatTime formats a time in milliseconds with the precision that a time range needs.
+func formatTime(ms, span int64) string {
+	t := time.UnixMilli(ms)
+	switch {
+	case span < int64(time.Minute/time.Millisecond):
+		return t.Format("15:04:05.000")
+	case span < int64(24*time.Hour/time.Millisecond):
+		return t.Format("15:04:05")
+	default:
+		return t.Format("2006-01-02 15:04")
+	}
+}
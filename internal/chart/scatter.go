@@ -0,0 +1,71 @@
+package chart
+
+import "math"
+
+// Position is a point of a scatter plot.
+type Position struct {
+	X float64
+	Y float64
+}
+
+// Group is a set of positions of a scatter plot, drawn in the same color.
+type Group struct {
+	Name      string
+	Positions []Position
+}
+
+// Scatter plots the groups in width x height cells, axes and legend included. Groups are
+// scaled to the same ranges, and colored in turn; where they overlap, the last one is visible.
+// Positions with infinite or NaN coordinates are skipped.
+func Scatter(groups []Group, width, height int) string {
+	groups = finiteGroups(groups)
+	x0, x1 := math.Inf(1), math.Inf(-1)
+	y0, y1 := math.Inf(1), math.Inf(-1)
+	for _, g := range groups {
+		for _, p := range g.Positions {
+			x0, x1 = math.Min(x0, p.X), math.Max(x1, p.X)
+			y0, y1 = math.Min(y0, p.Y), math.Max(y1, p.Y)
+		}
+	}
+	if x0 > x1 {
+		return axisStyle.Render("Nothing to plot.")
+	}
+	// A single position, or positions in line, are plotted in the middle
+	if x0 == x1 {
+		x0, x1 = x0-1, x1+1
+	}
+	if y0 == y1 {
+		y0, y1 = y0-1, y1+1
+	}
+
+	labels := [3]string{formatValue(y1), formatValue((y0 + y1) / 2), formatValue(y0)}
+	c := newCanvas(width, height, labels)
+	for i, g := range groups {
+		for _, p := range g.Positions {
+			x := scale(p.X, x0, x1, c.xDots)
+			// Dots are counted from the top
+			y := c.yDots - 1 - scale(p.Y, y0, y1, c.yDots)
+			c.dot(x, y, i)
+		}
+	}
+
+	names := make([]string, len(groups))
+	for i, g := range groups {
+		names[i] = g.Name
+	}
+	return c.render(labels, formatValue(x0), formatValue(x1), names)
+}
+
+// finiteGroups returns the groups without their positions that can't be scaled.
+func finiteGroups(groups []Group) []Group {
+	out := make([]Group, len(groups))
+	for i, g := range groups {
+		out[i] = Group{Name: g.Name, Positions: make([]Position, 0, len(g.Positions))}
+		for _, p := range g.Positions {
+			if finite(p.X) && finite(p.Y) {
+				out[i].Positions = append(out[i].Positions, p)
+			}
+		}
+	}
+	return out
+}
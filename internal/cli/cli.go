@@ -0,0 +1,219 @@
+// Package cli runs the subcommands of red, which print their result for scripts instead of starting the app.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/hirotake111/redisclient/internal/config"
+	"github.com/hirotake111/redisclient/internal/dump"
+	"github.com/hirotake111/redisclient/internal/source"
+	"github.com/redis/go-redis/v9"
+)
+
+// Exit codes of the subcommands.
+const (
+	ExitOK       = 0
+	ExitError    = 1 // The command failed, e.g. the server can't be reached
+	ExitUsage    = 2 // The arguments are invalid
+	ExitNotFound = 3 // The key doesn't exist
+)
+
+// Output formats.
+const (
+	OutputRaw   = "raw"
+	OutputJSON  = "json"
+	OutputTable = "table"
+)
+
+var errNotFound = errors.New("no such key")
+
+// options are the flags of a subcommand.
+type options struct {
+	db      int
+	profile string
+	output  string
+	format  string // Export format
+	file    string // Export file, "-" for the standard output
+}
+
+// env is what subcommands run with.
+type env struct {
+	src    source.Source
+	client *redis.Client
+	out    printer
+	stderr io.Writer
+	opts   options
+}
+
+type subcommand struct {
+	name    string
+	usage   string // Arguments, e.g. "<key>"
+	summary string
+	minArgs int
+	maxArgs int // -1 for any number of arguments
+	run     func(ctx context.Context, e env, args []string) error
+}
+
+var subcommands = []subcommand{
+	{name: "keys", usage: "[pattern]", summary: "list the keys matching a pattern, every key by default", maxArgs: 1, run: runKeys},
+	{name: "get", usage: "<key>", summary: "print the value of a key", minArgs: 1, maxArgs: 1, run: runGet},
+	{name: "ttl", usage: "<key>", summary: "print the remaining time to live of a key in seconds, -1 if it doesn't expire", minArgs: 1, maxArgs: 1, run: runTTL},
+	{name: "del", usage: "<key>...", summary: "delete keys and print how many were deleted", minArgs: 1, maxArgs: -1, run: runDel},
+	{name: "export", usage: "[pattern]", summary: "export the keys matching a pattern, every key by default", maxArgs: 1, run: runExport},
+	{name: "info", usage: "[section]", summary: "print information and statistics about the server", maxArgs: 1, run: runInfo},
+}
+
+// IsCommand reports whether name is a subcommand.
+func IsCommand(name string) bool {
+	_, ok := lookup(name)
+	return ok
+}
+
+func lookup(name string) (subcommand, bool) {
+	for _, c := range subcommands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return subcommand{}, false
+}
+
+// Usage describes the subcommands.
+func Usage(w io.Writer) {
+	fmt.Fprintln(w, "Commands (run red <command> -h for their flags):")
+	for _, c := range subcommands {
+		fmt.Fprintf(w, "  red %s %s\n    \t%s\n", c.name, c.usage, c.summary)
+	}
+	fmt.Fprintf(w, "Exit codes: %d success, %d failure, %d invalid arguments, %d key not found\n", ExitOK, ExitError, ExitUsage, ExitNotFound)
+}
+
+// Run runs the subcommand named by args[0] with the rest of args, and returns the exit code.
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	c, ok := lookup(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		return ExitUsage
+	}
+
+	fs := flag.NewFlagSet("red "+c.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: red %s [flags] %s\n%s\n\nFlags:\n", c.name, c.usage, c.summary)
+		fs.PrintDefaults()
+	}
+	var opts options
+	fs.IntVar(&opts.db, "db", -1, "database index, overriding the one of the URL or profile")
+	fs.StringVar(&opts.profile, "profile", "", "connection profile to use instead of REDIS_URL")
+	fs.StringVar(&opts.output, "output", OutputRaw, "output format: raw, json or table")
+	if c.name == "export" {
+		fs.StringVar(&opts.format, "format", dump.FormatJSON, "export format: json, ndjson, redis or resp")
+		fs.StringVar(&opts.file, "file", "-", "file to export to, - for the standard output")
+	}
+	pos, err := parse(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	if err != nil {
+		return ExitUsage
+	}
+	if len(pos) < c.minArgs || (c.maxArgs >= 0 && len(pos) > c.maxArgs) {
+		fs.Usage()
+		return ExitUsage
+	}
+	if opts.output != OutputRaw && opts.output != OutputJSON && opts.output != OutputTable {
+		fmt.Fprintf(stderr, "invalid output format %q, expected raw, json or table\n", opts.output)
+		return ExitUsage
+	}
+	if err := validate(c, opts); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+
+	client, err := connect(ctx, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+	defer client.Close()
+
+	e := env{
+		src:    source.NewServer(client),
+		client: client,
+		out:    printer{w: stdout, output: opts.output},
+		stderr: stderr,
+		opts:   opts,
+	}
+	log.Printf("Running command %s %v (db: %d, output: %s)", c.name, pos, client.Options().DB, opts.output)
+	err = c.run(ctx, e, pos)
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, errNotFound):
+		fmt.Fprintln(stderr, err)
+		return ExitNotFound
+	default:
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+}
+
+// validate checks the flags specific to a subcommand before connecting.
+func validate(c subcommand, opts options) error {
+	if c.name != "export" {
+		return nil
+	}
+	switch opts.format {
+	case dump.FormatJSON, dump.FormatNDJSON, dump.FormatInline, dump.FormatRESP:
+		return nil
+	default:
+		return fmt.Errorf("unknown export format %q, expected json, ndjson, redis or resp", opts.format)
+	}
+}
+
+// parse parses flags placed before or after the positional arguments, which it returns.
+// Arguments after "--" are positional, e.g. keys starting with a dash.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			return append(pos, rest...), nil
+		}
+		args = rest
+		if len(args) == 0 {
+			return pos, nil
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+}
+
+// connect connects to the server of the profile, or of REDIS_URL without a profile.
+func connect(ctx context.Context, opts options) (*redis.Client, error) {
+	cfg, err := config.GetConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if opts.profile != "" {
+		if err := cfg.UseProfile(opts.profile); err != nil {
+			return nil, err
+		}
+	}
+	if opts.db >= 0 {
+		cfg.Option.DB = opts.db
+	}
+	client := redis.NewClient(cfg.Option)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %w", cfg.Option.Addr, err)
+	}
+	return client, nil
+}
@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/dump"
+)
+
+func runKeys(ctx context.Context, e env, args []string) error {
+	pattern := "*"
+	if len(args) > 0 {
+		pattern = args[0]
+	}
+	var keys []string
+	switch msg := command.GetKeys(ctx, e.src, pattern)().(type) {
+	case command.KeysUpdatedMsg:
+		keys = msg.Keys
+	case command.InfoMsg:
+		return msgError(msg)
+	}
+	sort.Strings(keys)
+
+	rows := make([][]string, len(keys))
+	for i, k := range keys {
+		rows[i] = []string{k}
+	}
+	if keys == nil {
+		keys = []string{} // An empty JSON array rather than null
+	}
+	return e.out.print(keys, []string{"KEY"}, rows, lines(keys))
+}
+
+func runGet(ctx context.Context, e env, args []string) error {
+	key := args[0]
+	if err := exists(ctx, e, key); err != nil {
+		return err
+	}
+	// Values are read as they are exported, along with their type and TTL
+	rec, err := dump.FetchSource(ctx, e.src, key)
+	if err != nil {
+		return err
+	}
+
+	var header []string
+	var rows [][]string
+	var raw []string
+	switch rec.Type {
+	case "string":
+		s, err := rec.String()
+		if err != nil {
+			return err
+		}
+		header, rows, raw = []string{"VALUE"}, [][]string{{string(s)}}, []string{string(s)}
+
+	case "hash":
+		fields, err := rec.Hash()
+		if err != nil {
+			return err
+		}
+		header = []string{"FIELD", "VALUE"}
+		for _, f := range fields {
+			rows = append(rows, []string{string(f.Field), string(f.Value)})
+			raw = append(raw, string(f.Field)+"\t"+string(f.Value))
+		}
+
+	case "list", "set":
+		elements, err := rec.Elements()
+		if err != nil {
+			return err
+		}
+		header = []string{"INDEX", "VALUE"}
+		if rec.Type == "set" {
+			header = []string{"MEMBER"}
+		}
+		for i, el := range elements {
+			if rec.Type == "set" {
+				rows = append(rows, []string{string(el)})
+			} else {
+				rows = append(rows, []string{strconv.Itoa(i), string(el)})
+			}
+			raw = append(raw, string(el))
+		}
+
+	case "zset":
+		members, err := rec.ZSet()
+		if err != nil {
+			return err
+		}
+		header = []string{"MEMBER", "SCORE"}
+		for _, m := range members {
+			score := strconv.FormatFloat(m.Score, 'g', -1, 64)
+			rows = append(rows, []string{string(m.Member), score})
+			raw = append(raw, string(m.Member)+"\t"+score)
+		}
+
+	case "stream":
+		entries, err := rec.Stream()
+		if err != nil {
+			return err
+		}
+		header = []string{"ID", "FIELD", "VALUE"}
+		for _, en := range entries {
+			for _, f := range en.Fields {
+				rows = append(rows, []string{en.ID, string(f.Field), string(f.Value)})
+				raw = append(raw, en.ID+"\t"+string(f.Field)+"\t"+string(f.Value))
+			}
+		}
+	}
+	return e.out.print(rec, header, rows, lines(raw))
+}
+
+func runTTL(ctx context.Context, e env, args []string) error {
+	key := args[0]
+	if err := exists(ctx, e, key); err != nil {
+		return err
+	}
+	ttl, err := e.src.TTL(ctx, key)
+	if err != nil {
+		return err
+	}
+	seconds := int64(-1)
+	if ttl > 0 {
+		seconds = int64(ttl.Seconds())
+	}
+	s := strconv.FormatInt(seconds, 10)
+	v := struct {
+		Key string `json:"key"`
+		TTL int64  `json:"ttl"`
+	}{key, seconds}
+	return e.out.print(v, []string{"KEY", "TTL"}, [][]string{{key, s}}, s+"\n")
+}
+
+func runDel(ctx context.Context, e env, args []string) error {
+	n, err := e.client.Del(ctx, args...).Result()
+	if err != nil {
+		return err
+	}
+	s := strconv.FormatInt(n, 10)
+	v := struct {
+		Deleted int64 `json:"deleted"`
+	}{n}
+	return e.out.print(v, []string{"DELETED"}, [][]string{{s}}, s+"\n")
+}
+
+func runExport(ctx context.Context, e env, args []string) error {
+	var keys []string // Every key
+	if len(args) > 0 && args[0] != "*" {
+		switch msg := command.GetKeys(ctx, e.src, args[0])().(type) {
+		case command.KeysUpdatedMsg:
+			keys = msg.Keys
+			sort.Strings(keys)
+		case command.InfoMsg:
+			return msgError(msg)
+		}
+		if keys == nil {
+			keys = []string{} // No key matches, rather than every key
+		}
+	}
+
+	w, out := e.out.w, e.out
+	if e.opts.file != "-" {
+		f, err := os.Create(e.opts.file)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	} else {
+		// The summary must not mix with the exported keys
+		out.w = e.stderr
+	}
+	res, err := command.ExportKeys(ctx, e.src, keys, w, e.opts.format, func(done, total int64) {})
+	if err != nil {
+		return err
+	}
+	if res.Skipped > 0 {
+		fmt.Fprintf(e.stderr, "%d keys skipped, e.g. %v\n", res.Skipped, res.FirstErr)
+	}
+
+	exported, skipped := strconv.FormatInt(res.Exported, 10), strconv.FormatInt(res.Skipped, 10)
+	v := struct {
+		File     string `json:"file"`
+		Exported int64  `json:"exported"`
+		Skipped  int64  `json:"skipped"`
+	}{e.opts.file, res.Exported, res.Skipped}
+	return out.print(v, []string{"FILE", "EXPORTED", "SKIPPED"}, [][]string{{e.opts.file, exported, skipped}}, exported+"\n")
+}
+
+func runInfo(ctx context.Context, e env, args []string) error {
+	info, err := e.client.Info(ctx, args...).Result()
+	if err != nil {
+		return err
+	}
+
+	// The reply is made of "# Section" headers followed by "field:value" lines
+	sections := make(map[string]map[string]string)
+	var rows [][]string
+	section := ""
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if name, ok := strings.CutPrefix(line, "# "); ok {
+			section = strings.ToLower(name)
+			sections[section] = make(map[string]string)
+			continue
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok || section == "" {
+			continue
+		}
+		sections[section][field] = value
+		rows = append(rows, []string{section, field, value})
+	}
+	return e.out.print(sections, []string{"SECTION", "FIELD", "VALUE"}, rows, strings.ReplaceAll(info, "\r\n", "\n"))
+}
+
+// exists returns errNotFound if the key doesn't exist.
+func exists(ctx context.Context, e env, key string) error {
+	t, err := e.src.Type(ctx, key)
+	if err != nil {
+		return err
+	}
+	if t == "none" {
+		return fmt.Errorf("%w: %s", errNotFound, key)
+	}
+	return nil
+}
+
+// msgError returns the error of an error message from the command layer.
+func msgError(msg command.InfoMsg) error {
+	if it, ok := msg.InfoType.(command.InfoTypeError); ok {
+		return it.Err
+	}
+	return fmt.Errorf("%s", msg)
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hirotake111/redisclient/internal/format"
+)
+
+// printer prints results in the output format chosen with --output.
+type printer struct {
+	w      io.Writer
+	output string
+}
+
+// print prints v as JSON, rows as a table with the header, or raw as is,
+// depending on the output format.
+func (p printer) print(v any, header []string, rows [][]string, raw string) error {
+	switch p.output {
+	case OutputJSON:
+		return json.NewEncoder(p.w).Encode(v)
+	case OutputTable:
+		return p.table(header, rows)
+	default:
+		_, err := io.WriteString(p.w, raw)
+		return err
+	}
+}
+
+func (p printer) table(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range rows {
+		cells := make([]string, len(r))
+		for i, c := range r {
+			cells[i] = cell(c)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// cell makes a value displayable in a table cell, on a single line without tabs.
+func cell(s string) string {
+	return strings.NewReplacer("\n", `\n`, "\t", `\t`).Replace(format.Printable(s))
+}
+
+// lines joins values with newlines, ending with a newline unless there are none.
+func lines(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.Join(values, "\n") + "\n"
+}
@@ -0,0 +1,109 @@
+package clipboard
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Backend names, which can be used to override the automatic selection.
+const (
+	Auto   = "auto"
+	PBCopy = "pbcopy"
+	WLCopy = "wl-copy"
+	XClip  = "xclip"
+	XSel   = "xsel"
+	OSC52  = "osc52"
+)
+
+// Environment variables used to detect the session.
+const (
+	envSSH  = "SSH_CONNECTION"
+	envTTY  = "SSH_TTY"
+	envWL   = "WAYLAND_DISPLAY"
+	envX11  = "DISPLAY"
+	envTmux = "TMUX"
+)
+
+// Backend copies text to the system clipboard.
+type Backend interface {
+	Name() string
+	Copy(value string) error
+}
+
+// TooLargeError is returned when the value is too large to be copied, in which case
+// nothing is copied rather than part of the value.
+type TooLargeError struct {
+	Size int // Size of the value in bytes
+	Max  int // Size of the largest value that can be copied in bytes
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("value is too large for the clipboard (%d bytes, at most %d with OSC 52, see RED_OSC52_MAX_BYTES), nothing was copied", e.Size, e.Max)
+}
+
+// commandBackend pipes the value to an external program.
+type commandBackend struct {
+	name string
+	args []string
+}
+
+func (c commandBackend) Name() string { return c.name }
+
+func (c commandBackend) Copy(value string) error {
+	cmd := exec.Command(c.name, c.args...)
+	cmd.Stdin = strings.NewReader(value)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", c.name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+var commandBackends = map[string]commandBackend{
+	PBCopy: {name: PBCopy},
+	WLCopy: {name: WLCopy},
+	XClip:  {name: XClip, args: []string{"-selection", "clipboard"}},
+	XSel:   {name: XSel, args: []string{"--clipboard", "--input"}},
+}
+
+// Select returns the backend with the given name, or picks one for the current
+// environment if name is empty or Auto. Remote sessions use OSC 52, as the
+// clipboard of the machine running red is not the one the user is looking at.
+func Select(name string, maxOSC52Bytes int) (Backend, error) {
+	switch name {
+	case "", Auto:
+	case OSC52:
+		return NewOSC52(maxOSC52Bytes), nil
+	default:
+		b, ok := commandBackends[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown clipboard backend %q", name)
+		}
+		if _, err := exec.LookPath(b.name); err != nil {
+			return nil, fmt.Errorf("clipboard backend %s is not available: %w", name, err)
+		}
+		return b, nil
+	}
+
+	if os.Getenv(envSSH) != "" || os.Getenv(envTTY) != "" {
+		return NewOSC52(maxOSC52Bytes), nil
+	}
+
+	var candidates []string
+	switch {
+	case runtime.GOOS == "darwin":
+		candidates = []string{PBCopy}
+	case os.Getenv(envWL) != "":
+		candidates = []string{WLCopy, XClip, XSel}
+	case os.Getenv(envX11) != "":
+		candidates = []string{XClip, XSel}
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c); err == nil {
+			return commandBackends[c], nil
+		}
+	}
+	return NewOSC52(maxOSC52Bytes), nil
+}
@@ -0,0 +1,179 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakePath puts executables with the given names, which write their input to the file
+// named by $RED_CLIPBOARD_OUT, in a temporary directory that becomes the only one on PATH.
+func fakePath(t *testing.T, names ...string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake executables are shell scripts")
+	}
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat is not available")
+	}
+	dir := t.TempDir()
+	for _, name := range names {
+		script := "#!/bin/sh\n" + cat + " > \"$RED_CLIPBOARD_OUT\"\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+// session sets the environment variables that Select looks at, clearing the others.
+func session(t *testing.T, vars map[string]string) {
+	t.Helper()
+	for _, name := range []string{envSSH, envTTY, envWL, envX11, envTmux} {
+		t.Setenv(name, vars[name])
+	}
+}
+
+func TestSelect(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("pbcopy is always picked on macOS")
+	}
+	tests := []struct {
+		name string
+		path []string
+		env  map[string]string
+		want string
+	}{
+		{name: "wayland", path: []string{WLCopy, XClip, XSel}, env: map[string]string{envWL: "wayland-0", envX11: ":0"}, want: WLCopy},
+		{name: "xwayland without wl-copy", path: []string{XSel}, env: map[string]string{envWL: "wayland-0", envX11: ":0"}, want: XSel},
+		{name: "x11", path: []string{XClip, XSel}, env: map[string]string{envX11: ":0"}, want: XClip},
+		{name: "x11 with xsel only", path: []string{XSel}, env: map[string]string{envX11: ":0"}, want: XSel},
+		{name: "x11 ignores wl-copy", path: []string{WLCopy}, env: map[string]string{envX11: ":0"}, want: OSC52},
+		{name: "no display", path: []string{WLCopy, XClip, XSel}, want: OSC52},
+		{name: "ssh", path: []string{XClip}, env: map[string]string{envSSH: "10.0.0.1 22 10.0.0.2 22", envX11: ":0"}, want: OSC52},
+		{name: "ssh tty", path: []string{WLCopy}, env: map[string]string{envTTY: "/dev/pts/0", envWL: "wayland-0"}, want: OSC52},
+		{name: "nothing on path", env: map[string]string{envX11: ":0"}, want: OSC52},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakePath(t, tt.path...)
+			session(t, tt.env)
+			for _, name := range []string{"", Auto} {
+				b, err := Select(name, 100)
+				if err != nil {
+					t.Fatalf("Select(%q) failed: %v", name, err)
+				}
+				if b.Name() != tt.want {
+					t.Errorf("Select(%q) = %s, want %s", name, b.Name(), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectOverride(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  []string
+		want  string
+		isErr bool
+	}{
+		{name: XClip, path: []string{XClip}, want: XClip},
+		{name: XSel, path: []string{XClip, XSel}, want: XSel},
+		{name: WLCopy, path: []string{XClip}, isErr: true},
+		{name: PBCopy, path: []string{PBCopy}, want: PBCopy},
+		{name: OSC52, want: OSC52},
+		{name: "clip.exe", path: []string{"clip.exe"}, isErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakePath(t, tt.path...)
+			// Overrides win over the session
+			session(t, map[string]string{envSSH: "10.0.0.1 22 10.0.0.2 22", envX11: ":0"})
+			b, err := Select(tt.name, 100)
+			if tt.isErr {
+				if err == nil {
+					t.Fatalf("Select(%q) = %s, want an error", tt.name, b.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Select(%q) failed: %v", tt.name, err)
+			}
+			if b.Name() != tt.want {
+				t.Errorf("Select(%q) = %s, want %s", tt.name, b.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandCopy(t *testing.T) {
+	fakePath(t, XClip)
+	out := filepath.Join(t.TempDir(), "clipboard")
+	t.Setenv("RED_CLIPBOARD_OUT", out)
+	value := "héllo\nwörld"
+	if err := commandBackends[XClip].Copy(value); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != value {
+		t.Errorf("copied %q, want %q", got, value)
+	}
+}
+
+type buffer struct{ bytes.Buffer }
+
+func (*buffer) Close() error { return nil }
+
+func TestOSC52Copy(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		maxBytes int
+		tmux     bool
+		want     string
+		tooLarge bool
+	}{
+		{name: "plain", value: "hello", maxBytes: 100, want: "\x1b]52;c;aGVsbG8=\x07"},
+		{name: "tmux", value: "hello", maxBytes: 100, tmux: true, want: "\x1bPtmux;\x1b\x1b]52;c;aGVsbG8=\x07\x1b\\"},
+		{name: "at the cap", value: "hello", maxBytes: base64.StdEncoding.EncodedLen(5), want: "\x1b]52;c;aGVsbG8=\x07"},
+		{name: "over the cap", value: "hello world", maxBytes: base64.StdEncoding.EncodedLen(5), tooLarge: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w buffer
+			o := osc52Backend{maxBytes: tt.maxBytes, tmux: tt.tmux, open: func() (io.WriteCloser, error) { return &w, nil }}
+			err := o.Copy(tt.value)
+			var tle *TooLargeError
+			if tt.tooLarge {
+				if !errors.As(err, &tle) {
+					t.Fatalf("Copy(%q) = %v, want a TooLargeError", tt.value, err)
+				}
+				if w.Len() != 0 {
+					t.Errorf("Copy(%q) wrote %q, want nothing", tt.value, w.String())
+				}
+				if !strings.Contains(err.Error(), "nothing was copied") {
+					t.Errorf("error %q doesn't tell that nothing was copied", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Copy(%q) failed: %v", tt.value, err)
+			}
+			if w.String() != tt.want {
+				t.Errorf("Copy(%q) wrote %q, want %q", tt.value, w.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+)
+
+// osc52Backend writes the value to the terminal as an OSC 52 escape sequence,
+// which the terminal emulator puts in the clipboard. It works over SSH and in tmux.
+type osc52Backend struct {
+	maxBytes int // Maximum size of the encoded payload most terminals accept
+	tmux     bool
+	open     func() (io.WriteCloser, error)
+}
+
+func NewOSC52(maxBytes int) Backend {
+	return osc52Backend{
+		maxBytes: maxBytes,
+		tmux:     os.Getenv(envTmux) != "",
+		open:     openTerminal,
+	}
+}
+
+func (osc52Backend) Name() string { return OSC52 }
+
+func (o osc52Backend) Copy(value string) error {
+	if base64.StdEncoding.EncodedLen(len(value)) > o.maxBytes {
+		return &TooLargeError{Size: len(value), Max: base64.StdEncoding.DecodedLen(o.maxBytes)}
+	}
+
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(value)) + "\x07"
+	if o.tmux {
+		// Pass the sequence through tmux to the outer terminal
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+
+	w, err := o.open()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	_, err = io.WriteString(w, seq)
+	return err
+}
+
+// openTerminal opens the controlling terminal, so that the sequence doesn't go
+// through the output Bubble Tea renders to.
+func openTerminal() (io.WriteCloser, error) {
+	f, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return nopCloser{os.Stderr}, nil
+	}
+	return f, nil
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
@@ -28,4 +28,10 @@ var (
 
 	// Black
 	Black = lipgloss.Color("#1A1A1A")
+
+	// JSON string literals
+	JSONString = lipgloss.Color("#7FB069")
+
+	// JSON numbers
+	JSONNumber = lipgloss.Color("#6FA8DC")
 )
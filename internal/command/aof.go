@@ -0,0 +1,143 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/aof"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/format"
+	"github.com/hirotake111/redisclient/internal/source"
+)
+
+// maxArgLength shortens long arguments in timelines, e.g. large values.
+const maxArgLength = 80
+
+// AOFHistory reports every command of the append-only file that involves the key.
+func AOFHistory(l *aof.Log, db int, key string) tea.Cmd {
+	return func() tea.Msg {
+		cmds := l.History(db, key)
+		log.Printf("Found %d commands for key %q in DB %d", len(cmds), key, db)
+		header := fmt.Sprintf("%s in DB %d: %s commands", format.Printable(key), db, format.Count(int64(len(cmds))))
+		return ReportMsg{Title: "HISTORY", Body: timeline(header, cmds)}
+	}
+}
+
+// AOFTimeline reports the commands of the append-only file run on db, keeping the commands
+// with the given name (all if empty) involving a key matching the pattern (all if empty).
+func AOFTimeline(l *aof.Log, db int, name, pattern string) tea.Cmd {
+	return func() tea.Msg {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		pattern = strings.TrimSpace(pattern)
+		var cmds []aof.Command
+		for _, c := range l.Commands {
+			if c.DB != db || (name != "" && c.Name() != name) {
+				continue
+			}
+			if pattern != "" && !anyKeyMatches(c, pattern) {
+				continue
+			}
+			cmds = append(cmds, c)
+		}
+		header := fmt.Sprintf("%s, DB %d: %s of %s commands", l.Path, db, format.Count(int64(len(cmds))), format.Count(int64(len(l.Commands))))
+		if name != "" || pattern != "" {
+			header += fmt.Sprintf(" (command: %s, keys: %s)", orAll(name), orAll(pattern))
+		}
+		if l.Truncated {
+			header += "\nThe file ends with an incomplete command, which was ignored."
+		}
+		return ReportMsg{Title: "TIMELINE", Body: timeline(header, cmds)}
+	}
+}
+
+// ReplayAOF rebuilds the keys as they were after the first n commands of the append-only
+// file, e.g. the commands up to #n of the timeline, and browses them in db.
+func ReplayAOF(ctx context.Context, l *aof.Log, n, db int) tea.Cmd {
+	return func() tea.Msg {
+		n = min(max(n, 0), len(l.Commands))
+		mem, skipped := AOFSource(l, n)
+		src, _ := mem.SelectDB(ctx, db)
+		log.Printf("Replayed %d commands of %s, skipped: %v", n, l.Path, skipped)
+
+		txt := fmt.Sprintf("Replayed %s of %s commands.", format.Count(int64(n)), format.Count(int64(len(l.Commands))))
+		if len(skipped) > 0 {
+			txt += " Unsupported commands were skipped: " + strings.Join(skipped, ", ")
+			return tea.Batch(
+				func() tea.Msg { return NewSourceMsg{Source: src} },
+				NewWarningInfoCmd(infoid.New(), txt, expiration),
+			)()
+		}
+		return tea.Batch(
+			func() tea.Msg { return NewSourceMsg{Source: src} },
+			NewInfoInfoCmd(infoid.New(), txt, expiration),
+		)()
+	}
+}
+
+// AOFSource returns a read-only source with the keys as they were after the first n commands,
+// and the names of the commands that couldn't be replayed.
+func AOFSource(l *aof.Log, n int) (source.Source, []string) {
+	dbs, skipped := l.Replay(n)
+	return source.NewMemory(fmt.Sprintf("aof:%s@%d", filepath.Base(l.Path), n), dbs), skipped
+}
+
+// ParseAOFOffset parses a position in the timeline, e.g. "#12" or "12".
+func ParseAOFOffset(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(s), "#"))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid offset %q, expected a command number such as #12", s)
+	}
+	return n, nil
+}
+
+func anyKeyMatches(c aof.Command, pattern string) bool {
+	for _, k := range c.Keys() {
+		if source.Match(pattern, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func orAll(s string) string {
+	if s == "" {
+		return "all"
+	}
+	return s
+}
+
+// timeline renders commands one per line, with their position in the timeline and in their file.
+func timeline(header string, cmds []aof.Command) string {
+	var sb strings.Builder
+	sb.WriteString(header + "\n\n")
+	for i, c := range cmds {
+		if i == maxReportLines {
+			fmt.Fprintf(&sb, "... and %s more\n", format.Count(int64(len(cmds)-i)))
+			break
+		}
+		fmt.Fprintf(&sb, "#%-6d %s:%d", c.Index, c.File, c.Offset)
+		if !c.Time.IsZero() {
+			sb.WriteString("  " + c.Time.Format("2006-01-02 15:04:05"))
+		}
+		for _, a := range c.Args {
+			a = format.Printable(a)
+			a = truncateArg(a)
+			if a == "" || strings.ContainsAny(a, " \"") {
+				a = strconv.Quote(a)
+			}
+			sb.WriteString(" " + a)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// truncateArg shortens a long argument, a script line or a reply to fit a report line.
+func truncateArg(a string) string {
+	return format.Truncate(a, maxArgLength, "…")
+}
@@ -0,0 +1,172 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/cache"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/source"
+)
+
+// Actions of the bitmap form.
+const (
+	BitmapToggle   = "setbit"   // Toggles a bit with SETBIT
+	BitmapBitfield = "bitfield" // Reads the value as integers of a BITFIELD type
+)
+
+// BitmapActions lists the actions of the bitmap form, e.g. for a selector.
+var BitmapActions = []string{BitmapToggle, BitmapBitfield}
+
+// maxBitfieldValues is the number of integers a BITFIELD report lists at most.
+const maxBitfieldValues = 10000
+
+// ToggleBit flips the bit of a string at offset with SETBIT, and fetches the value again.
+func ToggleBit(ctx context.Context, src source.Source, c *cache.ValueCache, key, offset string, pageSize int64) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(offset), 10, 32)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("invalid bit offset %q, it must be between 0 and %d", offset, uint32(math.MaxUint32)), expiration)
+		}
+		if msg := checkBitmap(ctx, src, key); msg != nil {
+			return msg
+		}
+		old, err := client.GetBit(ctx, key, int64(n)).Result()
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if err := client.SetBit(ctx, key, int64(n), int(1-old)).Err(); err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		c.Invalidate(client.Options().DB, key)
+		log.Printf("Toggled bit %d of %s to %d", n, key, 1-old)
+
+		return tea.Batch(
+			NewInfoInfoCmd(infoid.New(), fmt.Sprintf("SETBIT %s %d %d (was %d)", key, n, 1-old, old), expiration),
+			GetValue(ctx, src, c, key, pageSize),
+		)()
+	}
+}
+
+// ShowBitfield lists the integers of a string, read one after the other from offset like
+// BITFIELD GET does. typ is a BITFIELD type, e.g. u8 or i16, and offset is a number of
+// bits, or a number of integers if prefixed with #. The type is u8 if empty.
+func ShowBitfield(ctx context.Context, src source.Source, key, typ, offset string) tea.Cmd {
+	return func() tea.Msg {
+		if typ == "" {
+			typ = "u8"
+		}
+		signed, width, err := parseBitfieldType(typ)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		start, err := parseBitfieldOffset(offset, width)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if msg := checkBitmap(ctx, src, key); msg != nil {
+			return msg
+		}
+		value, err := src.Get(ctx, key)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		data := []byte(value)
+
+		var sb strings.Builder
+		total := max(0, (int64(len(data))*8-start+int64(width)-1)/int64(width))
+		fmt.Fprintf(&sb, "%s from offset %d: %d integers", typ, start, total)
+		if total > maxBitfieldValues {
+			fmt.Fprintf(&sb, " (the first %d are listed)", maxBitfieldValues)
+		}
+		sb.WriteString("\n\n")
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintf(tw, "#\toffset\tvalue\t\n")
+		for i := int64(0); i < min(total, maxBitfieldValues); i++ {
+			at := start + i*int64(width)
+			fmt.Fprintf(tw, "%d\t%d\t%s\t\n", i, at, bitfieldValue(data, at, width, signed))
+		}
+		_ = tw.Flush()
+		log.Printf("Read %d %s integers of %s from offset %d", total, typ, key, start)
+		return ReportMsg{Title: "BITFIELD " + key + " " + typ, Body: strings.TrimSuffix(sb.String(), "\n")}
+	}
+}
+
+// checkBitmap returns a warning unless key holds a string, nil otherwise.
+func checkBitmap(ctx context.Context, src source.Source, key string) tea.Msg {
+	if key == "" {
+		return NewWarningMsg(infoid.New(), "Select a string first.", expiration)
+	}
+	t, err := src.Type(ctx, key)
+	if err != nil {
+		return NewErrorMsg(infoid.New(), err, expiration)
+	}
+	if t != "string" {
+		return NewWarningMsg(infoid.New(), fmt.Sprintf("%s is a %s, not a string.", key, t), expiration)
+	}
+	return nil
+}
+
+// parseBitfieldType parses a BITFIELD type: i1 to i64 for signed integers, and u1 to u63
+// for unsigned ones.
+func parseBitfieldType(typ string) (bool, int, error) {
+	invalid := fmt.Errorf("invalid type %q, e.g. u8 or i16 (i1 to i64, u1 to u63)", typ)
+	if len(typ) < 2 || (typ[0] != 'i' && typ[0] != 'u') {
+		return false, 0, invalid
+	}
+	signed := typ[0] == 'i'
+	width, err := strconv.Atoi(typ[1:])
+	if err != nil || width < 1 || width > 64 || (!signed && width == 64) {
+		return false, 0, invalid
+	}
+	return signed, width, nil
+}
+
+// parseBitfieldOffset parses the offset of a BITFIELD integer, a number of bits,
+// or a number of integers of width bits if prefixed with #. It is 0 if empty.
+func parseBitfieldOffset(offset string, width int) (int64, error) {
+	offset = strings.TrimSpace(offset)
+	if offset == "" {
+		return 0, nil
+	}
+	digits, unit := offset, int64(1)
+	if n, ok := strings.CutPrefix(offset, "#"); ok {
+		digits, unit = n, int64(width)
+	}
+	n, err := strconv.ParseUint(digits, 10, 32)
+	if err != nil || int64(n)*unit > math.MaxUint32 {
+		return 0, fmt.Errorf("invalid offset %q, e.g. 16, or #2 for the third integer", offset)
+	}
+	return int64(n) * unit, nil
+}
+
+// bitfieldValue reads the integer of width bits at a bit offset of data, most significant
+// bit first. Like BITFIELD GET, the bits past the end of data are zeros.
+func bitfieldValue(data []byte, offset int64, width int, signed bool) string {
+	var v uint64
+	for i := int64(0); i < int64(width); i++ {
+		bit := offset + i
+		v <<= 1
+		if bit/8 < int64(len(data)) && data[bit/8]&(0x80>>(bit%8)) != 0 {
+			v |= 1
+		}
+	}
+	if signed && width < 64 && v&(1<<(width-1)) != 0 {
+		// Sign extension
+		v |= math.MaxUint64 << width
+	}
+	if signed {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatUint(v, 10)
+}
@@ -2,15 +2,18 @@ package command
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"os/exec"
-	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/cache"
+	"github.com/hirotake111/redisclient/internal/clipboard"
 	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/format"
+	"github.com/hirotake111/redisclient/internal/source"
+	"github.com/hirotake111/redisclient/internal/values"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -22,8 +25,8 @@ func DisplayEmptyValue() tea.Msg {
 	return ValueUpdatedMsg{}
 }
 
-// GetKeys fetches keys from Redis matching the given pattern.
-func GetKeys(ctx context.Context, redis *redis.Client, pattern string) tea.Cmd {
+// GetKeys fetches keys from the source matching the given pattern.
+func GetKeys(ctx context.Context, src source.Source, pattern string) tea.Cmd {
 	const exp = 5 * time.Second
 
 	if pattern == "" {
@@ -31,167 +34,217 @@ func GetKeys(ctx context.Context, redis *redis.Client, pattern string) tea.Cmd {
 	}
 
 	return func() tea.Msg {
-		log.Printf("Fetching keys from Redis with pattern \"%s\", db: %d", pattern, redis.Options().DB)
-		keys, err := redis.Keys(ctx, pattern).Result()
+		log.Printf("Fetching keys from %s with pattern \"%s\", db: %d", src.Name(), pattern, src.DB())
+		keys, err := src.Keys(ctx, pattern)
 		if err != nil {
 			return NewErrorMsg(infoid.New(), err, exp)
 		}
 
-		log.Printf("Fetched %d keys from Redis(DB: %d)", len(keys), redis.Options().DB)
+		log.Printf("Fetched %d keys from %s(DB: %d)", len(keys), src.Name(), src.DB())
 		return KeysUpdatedMsg{Keys: keys}
 	}
 }
 
-func GetValue(ctx context.Context, redis *redis.Client, key string) tea.Cmd {
+// PollKeys fetches every key for the periodic refresh of the key list, which sorts them
+// with the metrics it already has rather than fetching them again for every key.
+func PollKeys(ctx context.Context, src source.Source) tea.Cmd {
+	get := GetKeys(ctx, src, "")
 	return func() tea.Msg {
-		log.Printf("Fetching value for key '%s' from Redis", key)
-		t, err := redis.Type(ctx, key).Result()
-		if err != nil {
-			log.Printf("Error fetching type for key %s: %v", key, err)
-			return NewErrorMsg(infoid.New(), err, expiration)
+		msg := get()
+		if m, ok := msg.(KeysUpdatedMsg); ok {
+			m.Polled = true
+			return m
 		}
+		return msg
+	}
+}
 
-		log.Printf("Fetching value for key \"%s\" of type %s", key, t)
-		var newValue string
-		switch t {
-		case "string":
-			value, err := redis.Get(ctx, key).Result()
-			if err != nil {
-				return NewErrorMsg(infoid.New(), err, expiration)
-			}
-			log.Printf("Fetched value for key \"%s\"", key)
-			newValue = escapeCharacter(value)
-
-		case "hash":
-			hm, err := redis.HGetAll(ctx, key).Result()
-			if err != nil {
-				return NewErrorMsg(infoid.New(), err, expiration)
-			}
-			bytes, err := json.Marshal(hm)
-			if err != nil {
-				return NewErrorMsg(infoid.New(), err, expiration)
+// GetValue fetches the value for the key, serving it from the cache if possible.
+// Only the first page of hashes, lists, sets and sorted sets is fetched.
+func GetValue(ctx context.Context, src source.Source, c *cache.ValueCache, key string, pageSize int64) tea.Cmd {
+	return func() tea.Msg {
+		db := src.DB()
+		if e, ok := c.Get(db, key); ok {
+			log.Printf("Using cached value for key \"%s\" (DB: %d)", key, db)
+			return ValueUpdatedMsg{
+				Key:        key,
+				NewValue:   e.Value,
+				Type:       e.Type,
+				Collection: e.Collection,
+				TTL:        e.RemainingTTL(time.Now()),
+				FetchedAt:  e.FetchedAt,
+				Cached:     true,
 			}
-			newValue = (string(bytes))
+		}
 
-		case "list":
-			list, err := redis.LRange(ctx, key, 0, -1).Result()
-			if err != nil {
-				return NewErrorMsg(infoid.New(), err, expiration)
-			}
-			bytes, err := json.Marshal(list)
-			if err != nil {
-				return NewErrorMsg(infoid.New(), err, expiration)
-			}
-			newValue = (string(bytes))
+		msg := fetchValue(ctx, src, key, pageSize)
+		if m, ok := msg.(ValueUpdatedMsg); ok {
+			c.Put(db, key, cache.Entry{
+				Value:      m.NewValue,
+				Type:       m.Type,
+				Collection: m.Collection,
+				TTL:        m.TTL,
+				FetchedAt:  m.FetchedAt,
+			})
+		}
+		return msg
+	}
+}
 
-		case "set":
-			members, err := redis.SMembers(ctx, key).Result()
-			if err != nil {
-				return NewErrorMsg(infoid.New(), err, expiration)
-			}
-			bytes, err := json.Marshal(members)
-			if err != nil {
-				return NewErrorMsg(infoid.New(), err, expiration)
-			}
-			newValue = (string(bytes))
+func fetchValue(ctx context.Context, src source.Source, key string, pageSize int64) tea.Msg {
+	log.Printf("Fetching value for key '%s' from %s", key, src.Name())
+	t, err := src.Type(ctx, key)
+	if err != nil {
+		log.Printf("Error fetching type for key %s: %v", key, err)
+		return NewErrorMsg(infoid.New(), err, expiration)
+	}
 
-		case "zset":
-			zset, err := redis.ZRangeWithScores(ctx, key, 0, -1).Result()
-			if err != nil {
-				return NewErrorMsg(infoid.New(), err, expiration)
-			}
-			// Convert ZSet to a map for easier display
-			zsetMap := make(map[string]float64)
-			for _, z := range zset {
-				zsetMap[z.Member.(string)] = z.Score
-			}
-			bytes, err := json.Marshal(zsetMap)
-			if err != nil {
-				return NewErrorMsg(infoid.New(), err, expiration)
-			}
-			newValue = (string(bytes))
+	log.Printf("Fetching value for key \"%s\" of type %s", key, t)
+	var newValue string
+	var collection *values.Collection
+	switch t {
+	case "string":
+		value, err := src.Get(ctx, key)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		log.Printf("Fetched value for key \"%s\"", key)
+		newValue = value // Kept as is, so that it can be decoded
+		if isHyperLogLog(value) {
+			t = TypeHyperLogLog
+			newValue = hyperLogLogValue(ctx, src, key, value)
+		}
 
-		case "none": // Key does not exist
-			log.Printf("Key %s does not exist in the database", key)
-			return NewErrorMsg(infoid.New(), fmt.Errorf("key %s does not exist in the database", key), expiration)
+	case "hash", "list", "set", "zset":
+		page, err := fetchPage(ctx, src, key, t, 0, pageSize)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		collection = &page
+		newValue = page.JSON()
 
-		default:
-			return NewErrorMsg(infoid.New(), fmt.Errorf("unsupported type %s for key %s", t, key), expiration)
+	case TypeJSON:
+		doc, err := getJSON(ctx, src, key, "")
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
 		}
+		newValue = doc // Rendered by the JSON viewer
 
-		log.Printf("Fetching TTL for key %s of type %s", key, t)
-		ttl, err := redis.TTL(ctx, key).Result()
+	case TypeTimeSeries:
+		value, err := timeSeriesValue(ctx, src, key)
 		if err != nil {
-			log.Printf("Error fetching TTL for key %s: %v", key, err)
+			return NewErrorMsg(infoid.New(), err, expiration)
 		}
-		return ValueUpdatedMsg{
-			NewValue: newValue,
-			TTL:      int64(ttl.Seconds()), // Convert TTL to seconds
+		newValue = value
+
+	case TypeBloom, TypeCuckoo, TypeCountMin, TypeTopK:
+		value, err := probabilisticValue(ctx, src, key, t)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
 		}
+		newValue = value
+
+	case "none": // Key does not exist
+		log.Printf("Key %s does not exist in the database", key)
+		return NewErrorMsg(infoid.New(), fmt.Errorf("key %s does not exist in the database", key), expiration)
+
+	default:
+		return NewErrorMsg(infoid.New(), fmt.Errorf("unsupported type %s for key %s", t, key), expiration)
 	}
-}
 
-func escapeCharacter(value string) string {
-	runes := make([]rune, 0, len(value))
-	for _, r := range value {
-		if r >= 32 {
-			runes = append(runes, r)
-		}
+	log.Printf("Fetching TTL for key %s of type %s", key, t)
+	ttl, err := src.TTL(ctx, key)
+	if err != nil {
+		log.Printf("Error fetching TTL for key %s: %v", key, err)
+	}
+	return ValueUpdatedMsg{
+		Key:        key,
+		NewValue:   newValue,
+		Type:       t,
+		Collection: collection,
+		TTL:        int64(ttl.Seconds()), // Convert TTL to seconds
+		FetchedAt:  time.Now(),
 	}
-	return string(runes)
 }
 
-func UpdateValue(ctx context.Context, client *redis.Client, key string, newValue string) tea.Cmd {
+func UpdateValue(ctx context.Context, src source.Source, c *cache.ValueCache, key string, newValue string) tea.Cmd {
 	return func() tea.Msg {
-		log.Printf("Updating key %s with new value %s", key, newValue)
+		log.Printf("Updating key %q with new value %q", key, truncate(newValue))
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
 		if err := client.Set(ctx, key, newValue, 0).Err(); err != nil {
 			return NewErrorMsg(infoid.New(), err, expiration)
 		}
+		c.Invalidate(client.Options().DB, key)
 
 		log.Printf("Updated key %s successfully", key)
 		return ValueUpdatedMsg{
-			NewValue: newValue,
+			Key:       key,
+			NewValue:  newValue,
+			Type:      "string",
+			FetchedAt: time.Now(),
 		}
 	}
 }
 
-func DeleteKey(ctx context.Context, client *redis.Client, key string) tea.Cmd {
+func DeleteKey(ctx context.Context, src source.Source, c *cache.ValueCache, key string) tea.Cmd {
 	return func() tea.Msg {
 		log.Printf("Deleting key \"%s\" from Redis", key)
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
 		if err := client.Del(ctx, key).Err(); err != nil {
 			return NewErrorMsg(infoid.New(), err, expiration)
 		}
+		c.Invalidate(client.Options().DB, key)
 		log.Printf("Deleted key \"%s\" successfully", key)
 		return KeyDeletedMsg{Key: key, info: "Key deleted successfully"}
 	}
 }
 
-func SwitchTab(ctx context.Context, client *redis.Client, tab int) tea.Cmd {
+func SwitchTab(ctx context.Context, src source.Source, tab int) tea.Cmd {
 	log.Printf("Switching to tab %d", tab)
-	client.Options().DB = tab
-	nc := redis.NewClient(client.Options())
 
 	return func() tea.Msg {
-		if _, err := nc.Ping(ctx).Result(); err != nil {
+		ns, err := src.SelectDB(ctx, tab)
+		if err != nil {
 			return NewErrorMsg(infoid.New(), err, expiration)
 		}
 		log.Printf("Switched to tab %d", tab)
-		return NewRedisClientMsg{Redis: nc} // No message needed for successful DB switch
+		return NewSourceMsg{Source: ns} // No message needed for successful DB switch
+	}
+}
+
+// writableClient returns the client of the source, or an error if the source is read-only.
+func writableClient(src source.Source) (*redis.Client, error) {
+	client := src.Client()
+	if client == nil {
+		return nil, source.ErrReadOnly
 	}
+	return client, nil
 }
 
-func CopyValueToClipboard(ctx context.Context, value string) tea.Cmd {
+// RequestCopy asks the model to copy the value to the clipboard with the configured backend.
+func RequestCopy(value string) tea.Cmd {
 	return func() tea.Msg {
-		var truncated = value
-		if len(value) > 10 {
-			truncated = value[:10] + "..." // Truncate long values for logging
-		}
-		log.Printf("Copying value to clipboard: %s", truncated)
-		// TODO: Implement platform-specific clipboard handling
-		// Currently only supports macOS (pbcopy)
-		command := exec.Command("pbcopy")
-		command.Stdin = strings.NewReader(value)
-		if err := command.Run(); err != nil {
+		return CopyRequestedMsg{Value: value}
+	}
+}
+
+func CopyValueToClipboard(ctx context.Context, backend clipboard.Backend, value string) tea.Cmd {
+	return func() tea.Msg {
+		truncated := format.Printable(truncate(value))
+		log.Printf("Copying value to clipboard with %s: %s", backend.Name(), truncated)
+		err := backend.Copy(value)
+		var te *clipboard.TooLargeError
+		if errors.As(err, &te) {
+			log.Printf("Value not copied to clipboard: %v", err)
+			return NewWarningMsg(infoid.New(), err.Error(), expiration)
+		}
+		if err != nil {
 			return NewErrorMsg(infoid.New(), fmt.Errorf("failed to copy value to clipboard: %w", err), expiration)
 		}
 
@@ -201,20 +254,32 @@ func CopyValueToClipboard(ctx context.Context, value string) tea.Cmd {
 	}
 }
 
+// truncate shortens long values for logging.
+func truncate(value string) string {
+	return format.Truncate(value, 10, "...")
+}
+
 func UpdateSelectedItemCmd(newKey string) tea.Msg {
 	return HighlightedKeyUpdatedMsg{}
 }
 
-func BulkDelete(ctx context.Context, client *redis.Client, keys []string) tea.Cmd {
+func BulkDelete(ctx context.Context, src source.Source, c *cache.ValueCache, keys []string) tea.Cmd {
 	return func() tea.Msg {
 		log.Printf("Bulk deleting %d keys from Redis", len(keys))
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
 		if err := client.Del(ctx, keys...).Err(); err != nil {
 			return NewErrorMsg(infoid.New(), err, expiration)
 		}
+		for _, k := range keys {
+			c.Invalidate(client.Options().DB, k)
+		}
 		log.Printf("Bulk deleted %d keys successfully", len(keys))
 
 		// Get new values for refreshing the key list
-		return GetKeys(ctx, client, "")()
+		return GetKeys(ctx, src, "")()
 	}
 }
 
@@ -225,7 +290,14 @@ func NewErrorInfoCmd(id infoid.InfoID, err error, expiresIn time.Duration) tea.C
 	}
 }
 
-// NewWarningInfoCmd is a helper function to create a info command.
+// NewWarningInfoCmd is a helper function to create a warning info command.
+func NewWarningInfoCmd(id infoid.InfoID, text string, expiresIn time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		return NewWarningMsg(id, text, expiresIn)
+	}
+}
+
+// NewInfoInfoCmd is a helper function to create a info command.
 func NewInfoInfoCmd(id infoid.InfoID, text string, expiresIn time.Duration) tea.Cmd {
 	return func() tea.Msg {
 		return NewInfoMsg(id, text, expiresIn)
@@ -0,0 +1,340 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/config"
+	"github.com/hirotake111/redisclient/internal/diff"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/dump"
+	"github.com/hirotake111/redisclient/internal/format"
+	"github.com/hirotake111/redisclient/internal/source"
+	"github.com/redis/go-redis/v9"
+)
+
+// ttlTolerance ignores the drift between the TTLs of keys that were copied with their TTL.
+const ttlTolerance = time.Second
+
+// CompareTargetMsg is sent when the source to compare keys with is ready.
+type CompareTargetMsg struct {
+	Source source.Source
+	Key    string // Key to compare the selected key with, empty to compare every key
+}
+
+func (c CompareTargetMsg) String() string {
+	return fmt.Sprintf("compare_target - source: %s/%d, key: %s", c.Source.Name(), c.Source.DB(), c.Key)
+}
+
+// OpenCompareTarget resolves the source the keys of src are compared with, see openTarget.
+func OpenCompareTarget(ctx context.Context, src source.Source, target, key string) tea.Cmd {
+	return func() tea.Msg {
+		dst, err := openTarget(ctx, src, target)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		return CompareTargetMsg{Source: dst, Key: strings.TrimSpace(key)}
+	}
+}
+
+// openTarget returns another database of src given its index, another server given
+// the name of its connection profile or its URL, or src itself if target is empty.
+func openTarget(ctx context.Context, src source.Source, target string) (source.Source, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return src, nil
+	}
+	if db, err := strconv.Atoi(target); err == nil {
+		return src.SelectDB(ctx, db)
+	}
+	var opt *redis.Options
+	var err error
+	if strings.Contains(target, "://") {
+		opt, err = redis.ParseURL(target)
+	} else {
+		opt, err = config.Profile(target)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expected a database number, a profile or a redis:// URL: %w", err)
+	}
+	client := redis.NewClient(opt)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to %s: %w", opt.Addr, err)
+	}
+	return source.NewServer(client), nil
+}
+
+// CloseTarget closes the connection that openTarget opened to dst, if any: dst is
+// neither src itself nor a source without a client.
+func CloseTarget(src, dst source.Source) {
+	if dst == nil || dst == src || dst.Client() == nil || (src != nil && dst.Client() == src.Client()) {
+		return
+	}
+	if err := dst.Client().Close(); err != nil {
+		log.Printf("Failed to close the connection to %s: %v", sideName(dst), err)
+	}
+}
+
+// Diff compares every key of left with the keys of right, reporting the keys found on one
+// side only and the keys whose type, TTL or value differ.
+func Diff(ctx context.Context, left, right source.Source) tea.Cmd {
+	return runTask(ctx, "Comparing", func(ctx context.Context, report Reporter) tea.Msg {
+		log.Printf("Comparing %s with %s", sideName(left), sideName(right))
+		leftKeys, err := scanKeys(ctx, left)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("failed to list keys of %s: %w", sideName(left), err), expiration)
+		}
+		rightKeys, err := scanKeys(ctx, right)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("failed to list keys of %s: %w", sideName(right), err), expiration)
+		}
+
+		inRight := make(map[string]bool, len(rightKeys))
+		for _, k := range rightKeys {
+			inRight[k] = true
+		}
+		var onlyLeft, onlyRight, different, failed []string
+		identical := 0
+		for i, k := range leftKeys {
+			if ctx.Err() != nil {
+				return nil
+			}
+			report(int64(i+1), int64(len(leftKeys)))
+			if !inRight[k] {
+				onlyLeft = append(onlyLeft, format.Printable(k))
+				continue
+			}
+			delete(inRight, k)
+			reasons, err := compareKey(ctx, left, right, k, k)
+			switch {
+			case err != nil:
+				failed = append(failed, fmt.Sprintf("%s: %v", format.Printable(k), err))
+			case len(reasons) > 0:
+				different = append(different, fmt.Sprintf("%s (%s)", format.Printable(k), strings.Join(reasons, ", ")))
+			default:
+				identical++
+			}
+		}
+		for _, k := range rightKeys {
+			if inRight[k] {
+				onlyRight = append(onlyRight, format.Printable(k))
+			}
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Left:  %s, %s keys\n", sideName(left), format.Count(int64(len(leftKeys))))
+		fmt.Fprintf(&sb, "Right: %s, %s keys\n", sideName(right), format.Count(int64(len(rightKeys))))
+		fmt.Fprintf(&sb, "Identical: %s, different: %s, only left: %s, only right: %s\n",
+			format.Count(int64(identical)), format.Count(int64(len(different))),
+			format.Count(int64(len(onlyLeft))), format.Count(int64(len(onlyRight))))
+		section(&sb, "DIFFERENT", different)
+		section(&sb, "ONLY LEFT", onlyLeft)
+		section(&sb, "ONLY RIGHT", onlyRight)
+		section(&sb, "NOT COMPARED", failed)
+
+		txt := fmt.Sprintf("Compared %s keys: %s different, %s only left, %s only right.",
+			format.Count(int64(len(leftKeys)+len(onlyRight))), format.Count(int64(len(different))),
+			format.Count(int64(len(onlyLeft))), format.Count(int64(len(onlyRight))))
+		log.Print(txt)
+		return tea.Batch(
+			func() tea.Msg { return ReportMsg{Title: "DIFF", Body: sb.String()} },
+			NewInfoInfoCmd(infoid.New(), txt+" Press = to compare the values of the selected key.", expiration),
+		)()
+	})
+}
+
+// DiffKey shows the values of leftKey in left and rightKey in right side by side, in width columns.
+func DiffKey(ctx context.Context, left source.Source, leftKey string, right source.Source, rightKey string, width int) tea.Cmd {
+	return func() tea.Msg {
+		a, errA := keyLines(ctx, left, leftKey)
+		b, errB := keyLines(ctx, right, rightKey)
+		if err := errors.Join(errA, errB); err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("failed to compare %q: %w", leftKey, err), expiration)
+		}
+		lines := diff.Lines(a, b)
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Left:  %s in %s\n", format.Printable(leftKey), sideName(left))
+		fmt.Fprintf(&sb, "Right: %s in %s\n\n", format.Printable(rightKey), sideName(right))
+		if !diff.Changed(lines) {
+			sb.WriteString("The keys are identical.\n\n")
+		}
+		sb.WriteString(diff.SideBySide(lines, width))
+		return ReportMsg{Title: "DIFF", Body: sb.String()}
+	}
+}
+
+// compareKey returns what differs between the keys: their type, TTL or value.
+func compareKey(ctx context.Context, left, right source.Source, leftKey, rightKey string) ([]string, error) {
+	a, err := dump.FetchSource(ctx, left, leftKey)
+	if err != nil {
+		return nil, err
+	}
+	b, err := dump.FetchSource(ctx, right, rightKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Type != b.Type {
+		return []string{fmt.Sprintf("type: %s ≠ %s", a.Type, b.Type)}, nil
+	}
+	var reasons []string
+	if ttl := time.Duration(a.TTL-b.TTL) * time.Millisecond; (a.TTL == 0) != (b.TTL == 0) || ttl.Abs() > ttlTolerance {
+		reasons = append(reasons, fmt.Sprintf("ttl: %s ≠ %s", ttlString(a.TTL), ttlString(b.TTL)))
+	}
+	la, err := valueLines(a)
+	if err != nil {
+		return nil, err
+	}
+	lb, err := valueLines(b)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Equal(la, lb) {
+		reasons = append(reasons, "value")
+	}
+	return reasons, nil
+}
+
+// keyLines returns the header and value lines of a key, which may not exist.
+func keyLines(ctx context.Context, src source.Source, key string) ([]string, error) {
+	t, err := src.Type(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if t == "none" {
+		return []string{"(no such key)"}, nil
+	}
+	rec, err := dump.FetchSource(ctx, src, key)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := valueLines(rec)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{"type: " + rec.Type, "ttl: " + ttlString(rec.TTL), ""}, lines...), nil
+}
+
+// valueLines renders the value of a record as comparable lines. Unordered elements,
+// i.e. hash fields and set members, are sorted so that they compare equal in any order.
+func valueLines(rec dump.Record) ([]string, error) {
+	var lines []string
+	switch rec.Type {
+	case "string":
+		s, err := rec.String()
+		if err != nil {
+			return nil, err
+		}
+		text, _ := format.IndentJSON(string(s))
+		return strings.Split(format.Printable(text), "\n"), nil
+
+	case "hash":
+		fields, err := rec.Hash()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			lines = append(lines, oneLine(string(f.Field))+" = "+oneLine(string(f.Value)))
+		}
+		sort.Strings(lines)
+
+	case "list", "set":
+		elements, err := rec.Elements()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range elements {
+			lines = append(lines, oneLine(string(e)))
+		}
+		if rec.Type == "set" {
+			sort.Strings(lines)
+		}
+
+	case "zset":
+		members, err := rec.ZSet()
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(members, func(i, j int) bool {
+			if members[i].Score != members[j].Score {
+				return members[i].Score < members[j].Score
+			}
+			return members[i].Member < members[j].Member
+		})
+		for _, m := range members {
+			lines = append(lines, fmt.Sprintf("%s %s", strconv.FormatFloat(m.Score, 'g', -1, 64), oneLine(string(m.Member))))
+		}
+
+	case "stream":
+		entries, err := rec.Stream()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			line := e.ID
+			for _, f := range e.Fields {
+				line += " " + oneLine(string(f.Field)) + "=" + oneLine(string(f.Value))
+			}
+			lines = append(lines, line)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported type %s", rec.Type)
+	}
+	return lines, nil
+}
+
+// scanKeys lists every key of the source, with SCAN for servers.
+func scanKeys(ctx context.Context, src source.Source) ([]string, error) {
+	client := src.Client()
+	if client == nil {
+		return src.Keys(ctx, "*")
+	}
+	var keys []string
+	iter := client.Scan(ctx, 0, "", exportScanCount).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// section writes a titled list of lines to a report, if there are any.
+func section(sb *strings.Builder, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "\n%s\n", title)
+	for i, l := range lines {
+		if i == maxReportLines {
+			fmt.Fprintf(sb, "... and %s more\n", format.Count(int64(len(lines)-i)))
+			break
+		}
+		fmt.Fprintf(sb, "  %s\n", l)
+	}
+}
+
+// oneLine makes an element displayable on a single line.
+func oneLine(s string) string {
+	return strings.NewReplacer("\n", `\n`, "\t", `\t`).Replace(format.Printable(s))
+}
+
+func sideName(src source.Source) string {
+	return fmt.Sprintf("%s/%d", src.Name(), src.DB())
+}
+
+func ttlString(ms int64) string {
+	if ms == 0 {
+		return "none"
+	}
+	return (time.Duration(ms) * time.Millisecond).Round(time.Second).String()
+}
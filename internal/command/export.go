@@ -0,0 +1,119 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/dump"
+	"github.com/hirotake111/redisclient/internal/format"
+	"github.com/hirotake111/redisclient/internal/source"
+)
+
+const exportScanCount = 1000
+
+// RequestExport asks the model to export keys, or the whole database if keys is nil.
+func RequestExport(keys []string, fileFormat string) tea.Cmd {
+	return func() tea.Msg {
+		return ExportRequestedMsg{Keys: keys, Format: fileFormat}
+	}
+}
+
+// Export writes keys, or every key in the database if keys is nil, to a JSON or NDJSON file.
+// Keys are read and written one at a time, so that large keyspaces don't need to fit in memory.
+func Export(ctx context.Context, src source.Source, keys []string, path, fileFormat string) tea.Cmd {
+	return runTask(ctx, "Exporting", func(ctx context.Context, report Reporter) tea.Msg {
+		log.Printf("Exporting keys to %s (format: %s)", path, fileFormat)
+		f, err := os.Create(path)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("failed to create export file: %w", err), expiration)
+		}
+		defer f.Close()
+
+		res, err := ExportKeys(ctx, src, keys, f, fileFormat, report)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+
+		txt := fmt.Sprintf("Exported %s keys to %s", format.Count(res.Exported), path)
+		log.Print(txt)
+		if res.Skipped > 0 {
+			txt += fmt.Sprintf(" (%s skipped, e.g. %v)", format.Count(res.Skipped), res.FirstErr)
+			return NewWarningMsg(infoid.New(), txt, expiration)
+		}
+		return NewInfoMsg(infoid.New(), txt, expiration)
+	})
+}
+
+// ExportResult counts the keys written by ExportKeys.
+type ExportResult struct {
+	Exported int64
+	Skipped  int64 // Keys that couldn't be read, e.g. because they expired
+	FirstErr error // Why the first key was skipped
+}
+
+// ExportKeys writes keys, or every key in the database if keys is nil, to w in the given format.
+func ExportKeys(ctx context.Context, src source.Source, keys []string, w io.Writer, fileFormat string, report Reporter) (ExportResult, error) {
+	var res ExportResult
+	dw, err := dump.NewWriter(w, fileFormat)
+	if err != nil {
+		return res, err
+	}
+
+	export := func(key string, total int64) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rec, err := dump.FetchSource(ctx, src, key)
+		if err != nil {
+			// The key may have expired or been deleted since it was listed
+			log.Printf("Skipping key %q: %v", key, err)
+			res.Skipped++
+			if res.FirstErr == nil {
+				res.FirstErr = err
+			}
+		} else if err := dw.Write(rec); err != nil {
+			return err
+		} else {
+			res.Exported++
+		}
+		report(res.Exported+res.Skipped, total)
+		return nil
+	}
+
+	client := src.Client()
+	if keys == nil && client == nil {
+		// Sources other than servers are in memory, listing every key is cheap
+		if keys, err = src.Keys(ctx, "*"); err != nil {
+			return res, fmt.Errorf("failed to list keys: %w", err)
+		}
+	}
+
+	if keys != nil {
+		for _, k := range keys {
+			if err := export(k, int64(len(keys))); err != nil {
+				return res, fmt.Errorf("failed to write export file: %w", err)
+			}
+		}
+	} else {
+		total, _ := client.DBSize(ctx).Result()
+		iter := client.Scan(ctx, 0, "", exportScanCount).Iterator()
+		for iter.Next(ctx) {
+			if err := export(iter.Val(), total); err != nil {
+				return res, fmt.Errorf("failed to write export file: %w", err)
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return res, fmt.Errorf("failed to scan keys: %w", err)
+		}
+	}
+
+	if err := dw.Close(); err != nil {
+		return res, fmt.Errorf("failed to write export file: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,296 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/cache"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/dump"
+	"github.com/hirotake111/redisclient/internal/source"
+	"github.com/redis/go-redis/v9"
+)
+
+// Actions of the functions browser.
+const (
+	FunctionList    = "list"
+	FunctionCode    = "code"
+	FunctionCall    = "fcall"
+	FunctionCallRO  = "fcall_ro"
+	FunctionLoad    = "load"
+	FunctionDelete  = "delete"
+	FunctionDump    = "dump"
+	FunctionRestore = "restore"
+)
+
+// FunctionActions lists every action, e.g. for a selector.
+var FunctionActions = []string{FunctionList, FunctionCode, FunctionCall, FunctionCallRO, FunctionLoad, FunctionDelete, FunctionDump, FunctionRestore}
+
+// What to do when a loaded or restored library already exists.
+const (
+	FunctionFail    = "fail"
+	FunctionReplace = "replace"
+)
+
+// FunctionPolicies lists the policies for existing libraries, e.g. for a selector.
+var FunctionPolicies = []string{FunctionFail, FunctionReplace}
+
+// ListFunctions reports the libraries matching pattern, every library if empty,
+// with their functions, flags and descriptions.
+func ListFunctions(ctx context.Context, src source.Source, pattern string) tea.Cmd {
+	return func() tea.Msg {
+		libs, err := functionLibraries(ctx, src, pattern)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if len(libs) == 0 {
+			if pattern != "" {
+				return NewWarningMsg(infoid.New(), fmt.Sprintf("No library matches %q.", pattern), expiration)
+			}
+			return NewWarningMsg(infoid.New(), "No function library is loaded, load one from a .lua file first.", expiration)
+		}
+
+		n := 0
+		for _, lib := range libs {
+			n += len(lib.Functions)
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d libraries, %d functions on %s\n", len(libs), n, src.Name())
+		for _, lib := range libs {
+			fmt.Fprintf(&sb, "\n%s (%s, %d lines)\n", lib.Name, lib.Engine, strings.Count(strings.TrimSpace(lib.Code), "\n")+1)
+			tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+			for _, f := range lib.Functions {
+				flags := strings.Join(f.Flags, ",")
+				if flags == "" {
+					flags = "-"
+				}
+				fmt.Fprintf(tw, "  %s\t%s\t%s\n", f.Name, flags, oneLine(f.Description))
+			}
+			_ = tw.Flush()
+		}
+		return ReportMsg{Title: "FUNCTIONS", Body: sb.String()}
+	}
+}
+
+// ShowFunctionCode displays the code of a library, or of the library defining a function, highlighted.
+func ShowFunctionCode(ctx context.Context, src source.Source, name string) tea.Cmd {
+	return func() tea.Msg {
+		if name == "" {
+			return NewWarningMsg(infoid.New(), "Enter the name of a library or function.", expiration)
+		}
+		libs, err := functionLibraries(ctx, src, "")
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		lib, ok := findLibrary(libs, name)
+		if !ok {
+			return NewWarningMsg(infoid.New(), fmt.Sprintf("No library or function named %q.", name), expiration)
+		}
+		return ReportMsg{Title: "LIBRARY " + lib.Name, Body: lib.Code, Syntax: SyntaxLua}
+	}
+}
+
+// CallFunction calls a function with FCALL, or FCALL_RO if readOnly is true. Keys and arguments
+// are separated by spaces, and may be quoted like in redis-cli.
+func CallFunction(ctx context.Context, src source.Source, c *cache.ValueCache, name, keys, args string, readOnly bool) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if name == "" {
+			return NewWarningMsg(infoid.New(), "Enter the name of the function to call.", expiration)
+		}
+		keyList, err := dump.SplitArgs(keys)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("invalid keys: %w", err), expiration)
+		}
+		argList, err := dump.SplitArgs(args)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("invalid arguments: %w", err), expiration)
+		}
+		argv := make([]any, len(argList))
+		for i, a := range argList {
+			argv[i] = a
+		}
+
+		cmd := "FCALL"
+		if readOnly {
+			cmd = "FCALL_RO"
+		}
+		log.Printf("Calling function %s with %s (keys: %d, args: %d)", name, cmd, len(keyList), len(argList))
+		start := time.Now()
+		var reply any
+		if readOnly {
+			reply, err = client.FCallRO(ctx, name, keyList, argv...).Result()
+		} else {
+			reply, err = client.FCall(ctx, name, keyList, argv...).Result()
+		}
+		took := time.Since(start)
+		if errors.Is(err, redis.Nil) {
+			reply, err = nil, nil
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s %s\nTook %s at %s\n\n", cmd, name, took.Round(time.Microsecond), start.Format(time.TimeOnly))
+		fmt.Fprintf(&sb, "KEYS: %s\nARGV: %s\n\n", dump.InlineCommand(keyList), dump.InlineCommand(argList))
+		if err != nil {
+			fmt.Fprintf(&sb, "(error) %v\n", functionError(err))
+		} else {
+			sb.WriteString(formatReply(reply))
+		}
+		msgs := []tea.Cmd{func() tea.Msg { return ReportMsg{Title: cmd, Body: sb.String()} }}
+		if !readOnly {
+			// The function may have changed any key
+			c.Purge()
+			msgs = append(msgs, GetKeys(ctx, src, ""))
+		}
+		return tea.Batch(msgs...)()
+	}
+}
+
+// LoadFunctionLibrary loads the library of a .lua file with FUNCTION LOAD, replacing
+// the library of the same name if replace is true.
+func LoadFunctionLibrary(ctx context.Context, src source.Source, path string, replace bool) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if path == "" {
+			return NewWarningMsg(infoid.New(), "Enter the path of the .lua file to load.", expiration)
+		}
+		code, err := os.ReadFile(path)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("failed to read library: %w", err), expiration)
+		}
+		var name string
+		if replace {
+			name, err = client.FunctionLoadReplace(ctx, string(code)).Result()
+		} else {
+			name, err = client.FunctionLoad(ctx, string(code)).Result()
+		}
+		if err != nil {
+			return NewErrorMsg(infoid.New(), functionError(err), expiration)
+		}
+		log.Printf("Loaded function library %s from %s (replace: %t)", name, path, replace)
+		return tea.Batch(
+			NewInfoInfoCmd(infoid.New(), fmt.Sprintf("Library %s loaded from %s.", name, path), expiration),
+			ListFunctions(ctx, src, name),
+		)()
+	}
+}
+
+// DeleteFunctionLibrary deletes a library and its functions with FUNCTION DELETE.
+func DeleteFunctionLibrary(ctx context.Context, src source.Source, name string) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if name == "" {
+			return NewWarningMsg(infoid.New(), "Enter the name of the library to delete.", expiration)
+		}
+		if err := client.FunctionDelete(ctx, name).Err(); err != nil {
+			return NewErrorMsg(infoid.New(), functionError(err), expiration)
+		}
+		log.Printf("Deleted function library %s", name)
+		return NewInfoMsg(infoid.New(), fmt.Sprintf("Library %s deleted.", name), expiration)
+	}
+}
+
+// DumpFunctions writes every library to a file with FUNCTION DUMP, to restore them on another server.
+func DumpFunctions(ctx context.Context, src source.Source, path string) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if path == "" {
+			return NewWarningMsg(infoid.New(), "Enter the path of the file to dump the libraries to.", expiration)
+		}
+		payload, err := client.FunctionDump(ctx).Result()
+		if err != nil {
+			return NewErrorMsg(infoid.New(), functionError(err), expiration)
+		}
+		if err := os.WriteFile(path, []byte(payload), 0o644); err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("failed to write dump: %w", err), expiration)
+		}
+		log.Printf("Dumped function libraries to %s (%d bytes)", path, len(payload))
+		return NewInfoMsg(infoid.New(), fmt.Sprintf("Libraries dumped to %s.", path), expiration)
+	}
+}
+
+// RestoreFunctions restores the libraries of a file written by DumpFunctions with FUNCTION RESTORE.
+// Existing libraries of the same name make it fail, unless replace is true.
+func RestoreFunctions(ctx context.Context, src source.Source, path string, replace bool) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if path == "" {
+			return NewWarningMsg(infoid.New(), "Enter the path of the dump to restore.", expiration)
+		}
+		payload, err := os.ReadFile(path)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("failed to read dump: %w", err), expiration)
+		}
+		policy := "APPEND"
+		if replace {
+			policy = "REPLACE"
+		}
+		if err := client.Do(ctx, "FUNCTION", "RESTORE", string(payload), policy).Err(); err != nil {
+			return NewErrorMsg(infoid.New(), functionError(err), expiration)
+		}
+		log.Printf("Restored function libraries from %s (policy: %s)", path, policy)
+		return tea.Batch(
+			NewInfoInfoCmd(infoid.New(), fmt.Sprintf("Libraries restored from %s.", path), expiration),
+			ListFunctions(ctx, src, ""),
+		)()
+	}
+}
+
+func functionLibraries(ctx context.Context, src source.Source, pattern string) ([]redis.Library, error) {
+	client, err := writableClient(src)
+	if err != nil {
+		return nil, err
+	}
+	libs, err := client.FunctionList(ctx, redis.FunctionListQuery{LibraryNamePattern: pattern, WithCode: true}).Result()
+	if err != nil {
+		return nil, functionError(err)
+	}
+	return libs, nil
+}
+
+// findLibrary returns the library named name, or else the library defining a function named name.
+func findLibrary(libs []redis.Library, name string) (redis.Library, bool) {
+	for _, lib := range libs {
+		if lib.Name == name {
+			return lib, true
+		}
+	}
+	for _, lib := range libs {
+		for _, f := range lib.Functions {
+			if f.Name == name {
+				return lib, true
+			}
+		}
+	}
+	return redis.Library{}, false
+}
+
+// functionError explains the errors of servers older than Redis 7, which have no functions.
+func functionError(err error) error {
+	if strings.Contains(err.Error(), "unknown command") {
+		return fmt.Errorf("functions require Redis 7 or later: %w", err)
+	}
+	return err
+}
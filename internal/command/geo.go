@@ -0,0 +1,341 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/chart"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/source"
+	"github.com/hirotake111/redisclient/internal/values"
+)
+
+// Actions of the geo form.
+const (
+	GeoPlot   = "plot"   // Lists and plots the members of a sorted set as positions
+	GeoRadius = "radius" // GEOSEARCH BYRADIUS
+	GeoBox    = "box"    // GEOSEARCH BYBOX
+)
+
+// GeoActions lists the actions of the geo form, e.g. for a selector.
+var GeoActions = []string{GeoPlot, GeoRadius, GeoBox}
+
+// GeoUnits lists the units of distances of GEOSEARCH.
+var GeoUnits = []string{"km", "m", "mi", "ft"}
+
+const (
+	// maxGeoMembers is the number of members that are plotted, or searched without a server, at most.
+	maxGeoMembers = 10000
+	// earthRadius is the radius of the Earth in meters that Redis computes distances with.
+	earthRadius = 6372797.560856
+)
+
+// geoUnitMeters converts the units of GeoUnits to meters.
+var geoUnitMeters = map[string]float64{"km": 1000, "m": 1, "mi": 1609.34, "ft": 0.3048}
+
+// geoMember is a member of a geo index, with its distance to the center of a search if any.
+type geoMember struct {
+	name     string
+	lon      float64
+	lat      float64
+	distance string
+}
+
+// PlotGeo lists the members of a sorted set as positions, and plots them in width x height
+// cells. Any sorted set can be plotted, including geo indexes that aren't detected as such.
+func PlotGeo(ctx context.Context, src source.Source, key string, width, height int) tea.Cmd {
+	return func() tea.Msg {
+		if msg := checkGeo(ctx, src, key); msg != nil {
+			return msg
+		}
+		page, err := fetchPage(ctx, src, key, "zset", 0, maxGeoMembers)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		members, err := geoPositions(ctx, src, key, page.Elements)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d members", page.Total)
+		if int64(len(members)) < page.Total {
+			fmt.Fprintf(&sb, " (the first %d are plotted)", len(members))
+		}
+		sb.WriteString(", longitude across and latitude up\n\n")
+		sb.WriteString(chart.Scatter([]chart.Group{{Name: key, Positions: geoChartPositions(members)}}, width, height-chartHeaderLines))
+		sb.WriteString("\n\n")
+		writeGeoMembers(&sb, members, "")
+		log.Printf("Plotted %d members of %s", len(members), key)
+		return ReportMsg{Title: "GEO " + key, Body: sb.String(), Syntax: SyntaxStyled}
+	}
+}
+
+// SearchGeo searches a geo index with GEOSEARCH, from a member or from coordinates given
+// as "longitude,latitude", within a radius or a box of "width height", nearest first.
+// The members found are listed with their distance, and plotted around the center.
+// Sources other than servers are searched in the same way without GEOSEARCH.
+func SearchGeo(ctx context.Context, src source.Source, key, action, from, size, unit, count string, width, height int) tea.Cmd {
+	return func() tea.Msg {
+		if msg := checkGeo(ctx, src, key); msg != nil {
+			return msg
+		}
+		from = strings.TrimSpace(from)
+		if from == "" {
+			return NewWarningMsg(infoid.New(), "Enter a member or coordinates to search from.", expiration)
+		}
+		sizes, err := parseGeoSize(size, action)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		limit := 0
+		if count = strings.TrimSpace(count); count != "" {
+			if limit, err = strconv.Atoi(count); err != nil || limit < 1 {
+				return NewErrorMsg(infoid.New(), fmt.Errorf("invalid count %q", count), expiration)
+			}
+		}
+		area := fmt.Sprintf("within %s %s", sizes[0], unit)
+		if action == GeoBox {
+			area = fmt.Sprintf("in a %s x %s %s box", sizes[0], sizes[1], unit)
+		}
+
+		center, isCoord := parseLonLat(from)
+		var found []geoMember
+		if client := src.Client(); client != nil {
+			args := []any{"GEOSEARCH", key}
+			if isCoord {
+				args = append(args, "FROMLONLAT", center.lon, center.lat)
+			} else {
+				args = append(args, "FROMMEMBER", from)
+			}
+			if action == GeoBox {
+				args = append(args, "BYBOX", sizes[0], sizes[1], unit)
+			} else {
+				args = append(args, "BYRADIUS", sizes[0], unit)
+			}
+			args = append(args, "ASC")
+			if limit > 0 {
+				args = append(args, "COUNT", limit)
+			}
+			args = append(args, "WITHDIST", "WITHCOORD")
+			reply, err := client.Do(ctx, args...).Slice()
+			if err != nil {
+				return NewErrorMsg(infoid.New(), err, expiration)
+			}
+			found = parseGeoSearch(reply)
+			if !isCoord {
+				pos, err := client.GeoPos(ctx, key, from).Result()
+				if err == nil && len(pos) == 1 && pos[0] != nil {
+					center = geoMember{lon: pos[0].Longitude, lat: pos[0].Latitude}
+				}
+			}
+		} else {
+			page, err := fetchPage(ctx, src, key, "zset", 0, maxGeoMembers)
+			if err != nil {
+				return NewErrorMsg(infoid.New(), err, expiration)
+			}
+			members, _ := geoPositions(ctx, src, key, page.Elements) // Decoded, without errors
+			if !isCoord {
+				i := slices.IndexFunc(members, func(m geoMember) bool { return m.name == from })
+				if i < 0 {
+					return NewWarningMsg(infoid.New(), fmt.Sprintf("%s is not a member of %s.", from, key), expiration)
+				}
+				center = members[i]
+			}
+			found = searchGeoMembers(members, center, action, sizes, geoUnitMeters[unit])
+			if limit > 0 && len(found) > limit {
+				found = found[:limit]
+			}
+		}
+		log.Printf("Found %d members of %s %s of %s", len(found), key, area, from)
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d members %s of %s, nearest first\n\n", len(found), area, from)
+		writeGeoMembers(&sb, found, unit)
+		if len(found) > 0 {
+			groups := []chart.Group{
+				{Name: "found", Positions: geoChartPositions(found)},
+				{Name: "center", Positions: []chart.Position{{X: center.lon, Y: center.lat}}},
+			}
+			sb.WriteString("\n")
+			sb.WriteString(chart.Scatter(groups, width, height-chartHeaderLines))
+		}
+		return ReportMsg{Title: "GEOSEARCH " + key, Body: sb.String(), Syntax: SyntaxStyled}
+	}
+}
+
+// searchGeoMembers returns the members within a radius, or a box of a width and height,
+// of center, nearest first, like GEOSEARCH does. Sizes are in units of unitMeters meters.
+func searchGeoMembers(members []geoMember, center geoMember, action string, sizes []string, unitMeters float64) []geoMember {
+	type match struct {
+		member geoMember
+		meters float64
+	}
+	var matches []match
+	for _, m := range members {
+		d := geoDistance(center.lon, center.lat, m.lon, m.lat)
+		if action == GeoBox {
+			w, _ := strconv.ParseFloat(sizes[0], 64)
+			h, _ := strconv.ParseFloat(sizes[1], 64)
+			// Like Redis, the box is measured along the meridian of the center
+			// and along the parallel of the member
+			latDistance := earthRadius * math.Abs((m.lat-center.lat)*math.Pi/180)
+			lonDistance := geoDistance(center.lon, m.lat, m.lon, m.lat)
+			if latDistance > h*unitMeters/2 || lonDistance > w*unitMeters/2 {
+				continue
+			}
+		} else if r, _ := strconv.ParseFloat(sizes[0], 64); d > r*unitMeters {
+			continue
+		}
+		matches = append(matches, match{m, d})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].meters < matches[j].meters })
+
+	found := make([]geoMember, len(matches))
+	for i, m := range matches {
+		found[i] = m.member
+		found[i].distance = strconv.FormatFloat(m.meters/unitMeters, 'f', 4, 64)
+	}
+	return found
+}
+
+// geoDistance returns the distance in meters between two positions with the haversine
+// formula, like GEODIST.
+func geoDistance(lon1, lat1, lon2, lat2 float64) float64 {
+	rad := func(d float64) float64 { return d * math.Pi / 180 }
+	u := math.Sin((rad(lat2) - rad(lat1)) / 2)
+	v := math.Sin((rad(lon2) - rad(lon1)) / 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(u*u+math.Cos(rad(lat1))*math.Cos(rad(lat2))*v*v))
+}
+
+// checkGeo returns a warning unless key holds a sorted set, nil otherwise.
+func checkGeo(ctx context.Context, src source.Source, key string) tea.Msg {
+	if key == "" {
+		return NewWarningMsg(infoid.New(), "Select a geo index first.", expiration)
+	}
+	t, err := src.Type(ctx, key)
+	if err != nil {
+		return NewErrorMsg(infoid.New(), err, expiration)
+	}
+	if t != "zset" {
+		return NewWarningMsg(infoid.New(), fmt.Sprintf("%s is a %s, not a geo index (sorted set).", key, t), expiration)
+	}
+	return nil
+}
+
+// geoPositions returns the positions of members with GEOPOS, or decoded from their scores
+// for sources other than servers, which never fails. Members that no longer exist are skipped.
+func geoPositions(ctx context.Context, src source.Source, key string, elements []values.Element) ([]geoMember, error) {
+	members := make([]geoMember, 0, len(elements))
+	client := src.Client()
+	if client == nil {
+		for _, e := range elements {
+			lon, lat := values.DecodeGeohash(e.Score)
+			members = append(members, geoMember{name: e.Field, lon: lon, lat: lat})
+		}
+		return members, nil
+	}
+	if len(elements) == 0 {
+		return members, nil
+	}
+	names := make([]string, len(elements))
+	for i, e := range elements {
+		names[i] = e.Field
+	}
+	pos, err := client.GeoPos(ctx, key, names...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range pos {
+		if p != nil && i < len(names) {
+			members = append(members, geoMember{name: names[i], lon: p.Longitude, lat: p.Latitude})
+		}
+	}
+	return members, nil
+}
+
+// parseLonLat parses coordinates given as "longitude,latitude" or "longitude latitude".
+func parseLonLat(s string) (geoMember, bool) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(parts) != 2 {
+		return geoMember{}, false
+	}
+	lon, err1 := strconv.ParseFloat(parts[0], 64)
+	lat, err2 := strconv.ParseFloat(parts[1], 64)
+	if err1 != nil || err2 != nil || math.Abs(lon) > 180 || math.Abs(lat) > values.MaxLatitude {
+		return geoMember{}, false
+	}
+	return geoMember{lon: lon, lat: lat}, true
+}
+
+// parseGeoSize parses the radius of a search, or the width and height of its box.
+func parseGeoSize(size, action string) ([]string, error) {
+	want, example := 1, "e.g. 5 or 0.5"
+	if action == GeoBox {
+		want, example = 2, "the width and height, e.g. 10 20"
+	}
+	parts := strings.Fields(size)
+	if len(parts) != want {
+		return nil, fmt.Errorf("invalid size %q, %s", size, example)
+	}
+	for _, p := range parts {
+		if n, err := strconv.ParseFloat(p, 64); err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid size %q, %s", size, example)
+		}
+	}
+	return parts, nil
+}
+
+// parseGeoSearch parses the reply of GEOSEARCH WITHDIST WITHCOORD, a list of
+// [member, distance, [longitude, latitude]].
+func parseGeoSearch(reply []any) []geoMember {
+	var members []geoMember
+	for _, r := range reply {
+		l, ok := r.([]any)
+		if !ok || len(l) != 3 {
+			continue
+		}
+		coord, _ := l[2].([]any)
+		if len(coord) != 2 {
+			continue
+		}
+		lon, _ := strconv.ParseFloat(replyString(coord[0]), 64)
+		lat, _ := strconv.ParseFloat(replyString(coord[1]), 64)
+		members = append(members, geoMember{name: replyString(l[0]), lon: lon, lat: lat, distance: replyString(l[1])})
+	}
+	return members
+}
+
+// writeGeoMembers lists members with their positions, and their distance in unit if not empty.
+func writeGeoMembers(sb *strings.Builder, members []geoMember, unit string) {
+	tw := tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+	if unit != "" {
+		fmt.Fprintf(tw, "member\tdistance (%s)\tlongitude\tlatitude\n", unit)
+	} else {
+		fmt.Fprintf(tw, "member\tlongitude\tlatitude\n")
+	}
+	for _, m := range members {
+		if unit != "" {
+			fmt.Fprintf(tw, "%s\t%s\t%.6f\t%.6f\n", oneLine(m.name), m.distance, m.lon, m.lat)
+		} else {
+			fmt.Fprintf(tw, "%s\t%.6f\t%.6f\n", oneLine(m.name), m.lon, m.lat)
+		}
+	}
+	_ = tw.Flush()
+}
+
+func geoChartPositions(members []geoMember) []chart.Position {
+	positions := make([]chart.Position, len(members))
+	for i, m := range members {
+		positions[i] = chart.Position{X: m.lon, Y: m.lat}
+	}
+	return positions
+}
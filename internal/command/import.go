@@ -0,0 +1,166 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/cache"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/dump"
+	"github.com/hirotake111/redisclient/internal/format"
+	"github.com/hirotake111/redisclient/internal/source"
+)
+
+const (
+	importBatchSize = 100
+	// maxReportLines keeps the import report displayable for huge files.
+	maxReportLines = 10000
+)
+
+// Import loads the records of a JSON or NDJSON file into the database. A record that fails
+// is reported and doesn't stop the import. With dryRun nothing is written, and the report
+// lists what would change.
+func Import(ctx context.Context, src source.Source, c *cache.ValueCache, path string, policy dump.Policy, dryRun bool) tea.Cmd {
+	name := "Importing"
+	if dryRun {
+		name = "Checking import"
+	}
+	return runTask(ctx, name, func(ctx context.Context, report Reporter) tea.Msg {
+		log.Printf("Importing %s (policy: %s, dry run: %v)", path, policy, dryRun)
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("failed to open import file: %w", err), expiration)
+		}
+		defer f.Close()
+
+		r, err := dump.NewReader(f)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("failed to read import file: %w", err), expiration)
+		}
+
+		im := dump.NewImporter(client, policy, dryRun)
+		var results []dump.Result
+		var batch []dump.Record
+		var readErr error
+		aborted := false
+
+		flush := func() error {
+			res, stop, err := im.Import(ctx, batch)
+			if err != nil {
+				return err
+			}
+			results = append(results, res...)
+			aborted = stop
+			batch = batch[:0]
+			report(int64(len(results)), 0)
+			return nil
+		}
+
+		for !aborted {
+			rec, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			var re *dump.RecordError
+			if errors.As(err, &re) {
+				results = append(results, dump.Result{Key: dump.Bytes(fmt.Sprintf("(record %d)", re.Index)), Action: dump.ActionFail, Err: re.Err})
+				continue
+			}
+			if err != nil {
+				// The file is not valid JSON anymore, nothing after this point can be read
+				readErr = err
+				break
+			}
+			batch = append(batch, rec)
+			if len(batch) == importBatchSize {
+				if err := flush(); err != nil {
+					return NewErrorMsg(infoid.New(), err, expiration)
+				}
+			}
+		}
+		if len(batch) > 0 && !aborted {
+			if err := flush(); err != nil {
+				return NewErrorMsg(infoid.New(), err, expiration)
+			}
+		}
+
+		if !dryRun {
+			c.Purge()
+		}
+		summary := importSummary(results, dryRun, aborted, readErr)
+		log.Print(summary)
+		body := resultReport(fmt.Sprintf("%s → DB %d", path, client.Options().DB), summary, results)
+		msgs := []tea.Cmd{
+			func() tea.Msg { return ReportMsg{Title: "IMPORT REPORT", Body: body} },
+			NewInfoInfoCmd(infoid.New(), summary, expiration),
+		}
+		if !dryRun {
+			msgs = append(msgs, GetKeys(ctx, src, ""))
+		}
+		return tea.Batch(msgs...)()
+	})
+}
+
+func importSummary(results []dump.Result, dryRun, aborted bool, readErr error) string {
+	txt := "Import: "
+	if dryRun {
+		txt = "Import (dry run): "
+	}
+	txt += countActions(results, "no records")
+	if aborted {
+		txt += ", aborted on an existing key"
+	}
+	if readErr != nil {
+		txt += fmt.Sprintf(", stopped reading the file: %v", readErr)
+	}
+	return txt
+}
+
+// countActions summarizes the number of results of each action, e.g. "create 10, skip 2".
+func countActions(results []dump.Result, none string) string {
+	counts := make(map[string]int64)
+	for _, r := range results {
+		counts[r.Action]++
+	}
+	var parts []string
+	for _, a := range []string{dump.ActionCreate, dump.ActionOverwrite, dump.ActionRename, dump.ActionSkip, dump.ActionFail} {
+		if counts[a] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %s", a, format.Count(counts[a])))
+		}
+	}
+	if len(parts) == 0 {
+		return none
+	}
+	return strings.Join(parts, ", ")
+}
+
+// resultReport lists the result of every key, after a header and a summary.
+func resultReport(header, summary string, results []dump.Result) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n%s\n\n", header, summary)
+	for i, r := range results {
+		if i == maxReportLines {
+			fmt.Fprintf(&sb, "... and %s more\n", format.Count(int64(len(results)-i)))
+			break
+		}
+		line := fmt.Sprintf("%-9s %s", r.Action, format.Printable(string(r.Key)))
+		if r.Target != r.Key && r.Target != "" {
+			line += " → " + format.Printable(string(r.Target))
+		}
+		if r.Err != nil {
+			line += ": " + r.Err.Error()
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
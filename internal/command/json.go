@@ -0,0 +1,150 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/cache"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/source"
+	"github.com/redis/go-redis/v9"
+)
+
+// TypeJSON is the type of RedisJSON documents, as returned by TYPE.
+const TypeJSON = "ReJSON-RL"
+
+// Actions on the paths of JSON documents.
+const (
+	JSONGet       = "get"
+	JSONSet       = "set"
+	JSONDel       = "del"
+	JSONArrAppend = "arrappend"
+	JSONNumIncrBy = "numincrby"
+)
+
+// JSONActions lists every action, e.g. for a selector.
+var JSONActions = []string{JSONGet, JSONSet, JSONDel, JSONArrAppend, JSONNumIncrBy}
+
+// QueryJSON reports the values matching a JSONPath in a document, e.g. $..price, with JSON.GET.
+func QueryJSON(ctx context.Context, src source.Source, key, path string) tea.Cmd {
+	return func() tea.Msg {
+		if msg := checkJSON(ctx, src, key); msg != nil {
+			return msg
+		}
+		if path == "" {
+			path = "$"
+		}
+		res, err := getJSON(ctx, src, key, path)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		log.Printf("Queried %s of JSON document %s", path, key)
+		return ReportMsg{Title: "JSON " + path, Body: res}
+	}
+}
+
+// EditJSON changes the values matching a JSONPath in a document with JSON.SET, JSON.DEL,
+// JSON.ARRAPPEND or JSON.NUMINCRBY, and fetches the document again.
+func EditJSON(ctx context.Context, src source.Source, c *cache.ValueCache, key, action, path, value string, pageSize int64) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if msg := checkJSON(ctx, src, key); msg != nil {
+			return msg
+		}
+		if path == "" {
+			path = "$"
+		}
+		switch action {
+		case JSONSet, JSONArrAppend:
+			if !json.Valid([]byte(value)) {
+				return NewErrorMsg(infoid.New(), fmt.Errorf("invalid JSON value %s, strings must be quoted", truncate(value)), expiration)
+			}
+		case JSONNumIncrBy:
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return NewErrorMsg(infoid.New(), fmt.Errorf("invalid number %q", value), expiration)
+			}
+		}
+
+		var res any
+		switch action {
+		case JSONSet:
+			res, err = client.Do(ctx, "JSON.SET", key, path, value).Result()
+		case JSONDel:
+			res, err = client.Do(ctx, "JSON.DEL", key, path).Result()
+		case JSONArrAppend:
+			res, err = client.Do(ctx, "JSON.ARRAPPEND", key, path, value).Result()
+		case JSONNumIncrBy:
+			res, err = client.Do(ctx, "JSON.NUMINCRBY", key, path, value).Result()
+		default:
+			return NewErrorMsg(infoid.New(), fmt.Errorf("unknown JSON action %q", action), expiration)
+		}
+		if errors.Is(err, redis.Nil) {
+			// JSON.SET with NX/XX conditions, or paths matching nothing
+			res, err = nil, nil
+		}
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		c.Invalidate(client.Options().DB, key)
+		log.Printf("Applied %s to %s of JSON document %s: %v", action, path, key, res)
+
+		return tea.Batch(
+			NewInfoInfoCmd(infoid.New(), fmt.Sprintf("JSON.%s %s: %s", strings.ToUpper(action), path, jsonResult(action, res)), expiration),
+			GetValue(ctx, src, c, key, pageSize),
+		)()
+	}
+}
+
+// getJSON returns the document at the root, or the values matching path as a JSON array.
+func getJSON(ctx context.Context, src source.Source, key, path string) (string, error) {
+	client := src.Client()
+	if client == nil {
+		return "", fmt.Errorf("%s can't read JSON documents", src.Name())
+	}
+	args := []any{"JSON.GET", key}
+	if path != "" {
+		args = append(args, path)
+	}
+	return client.Do(ctx, args...).Text()
+}
+
+// checkJSON returns a warning if the key is not a JSON document, nil otherwise.
+func checkJSON(ctx context.Context, src source.Source, key string) tea.Msg {
+	if key == "" {
+		return NewWarningMsg(infoid.New(), "Select a JSON document first.", expiration)
+	}
+	t, err := src.Type(ctx, key)
+	if err != nil {
+		return NewErrorMsg(infoid.New(), err, expiration)
+	}
+	if t != TypeJSON {
+		return NewWarningMsg(infoid.New(), fmt.Sprintf("%s is a %s, not a JSON document.", key, t), expiration)
+	}
+	return nil
+}
+
+// jsonResult describes the reply of an edit, e.g. the number of deleted paths.
+func jsonResult(action string, res any) string {
+	switch action {
+	case JSONDel:
+		return fmt.Sprintf("%v paths deleted", res)
+	case JSONArrAppend:
+		return "new lengths " + oneLine(fmt.Sprint(res))
+	case JSONNumIncrBy:
+		return "new values " + oneLine(fmt.Sprint(res))
+	default:
+		if res == nil {
+			return "not set"
+		}
+		return fmt.Sprint(res)
+	}
+}
@@ -0,0 +1,328 @@
+package command
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/dump"
+	"github.com/hirotake111/redisclient/internal/format"
+	"github.com/hirotake111/redisclient/internal/source"
+	"github.com/redis/go-redis/v9"
+)
+
+// Actions of the Lua script workbench.
+const (
+	LuaEvalSHA = "evalsha"
+	LuaEval    = "eval"
+	LuaExists  = "script exists"
+	LuaFlush   = "script flush"
+	LuaHistory = "history"
+)
+
+// LuaActions lists every action, e.g. for a selector.
+var LuaActions = []string{LuaEvalSHA, LuaEval, LuaExists, LuaFlush, LuaHistory}
+
+// LuaRun is a run of a Lua script, kept in the history of the workbench.
+type LuaRun struct {
+	Index  int // 1-based position in the history
+	Script string
+	Path   string // File the script was read from, if any
+	SHA    string // SHA1 digest of the script, as used by EVALSHA
+	Keys   []string
+	Args   []string
+	Mode   string // LuaEval or LuaEvalSHA
+	Loaded bool   // Whether the script was loaded with SCRIPT LOAD before EVALSHA
+	At     time.Time
+	Took   time.Duration
+	Reply  string // Formatted reply
+	Err    error
+}
+
+// LuaRanMsg is sent when a script has run, successfully or not.
+type LuaRanMsg struct {
+	Run LuaRun
+}
+
+func (l LuaRanMsg) String() string {
+	return fmt.Sprintf("lua_ran - index: %d, sha: %s, took: %s", l.Run.Index, l.Run.SHA, l.Run.Took)
+}
+
+// RunLua runs a script with EVAL, or with EVALSHA after loading it with SCRIPT LOAD
+// if the server doesn't know it yet. script is Lua code, the path of a .lua file, or
+// #N to run the script of the Nth run of history again, with its keys and arguments
+// when keys and args are empty. Keys and arguments are separated by spaces, and may
+// be quoted like in redis-cli.
+func RunLua(ctx context.Context, src source.Source, history []LuaRun, script, keys, args, mode string) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		run, err := newLuaRun(history, script, keys, args)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		run.Mode = mode
+		argv := make([]any, len(run.Args))
+		for i, a := range run.Args {
+			argv[i] = a
+		}
+
+		log.Printf("Running Lua script %s with %s (keys: %d, args: %d)", run.SHA, mode, len(run.Keys), len(run.Args))
+		run.At = time.Now()
+		var reply any
+		if mode == LuaEval {
+			reply, err = client.Eval(ctx, run.Script, run.Keys, argv...).Result()
+		} else {
+			reply, err = client.EvalSha(ctx, run.SHA, run.Keys, argv...).Result()
+			if err != nil && redis.HasErrorPrefix(err, "NOSCRIPT") {
+				if _, err = client.ScriptLoad(ctx, run.Script).Result(); err == nil {
+					run.Loaded = true
+					reply, err = client.EvalSha(ctx, run.SHA, run.Keys, argv...).Result()
+				}
+			}
+		}
+		run.Took = time.Since(run.At)
+		if errors.Is(err, redis.Nil) {
+			reply, err = nil, nil
+		}
+		run.Err = err
+		if err == nil {
+			run.Reply = formatReply(reply)
+		}
+
+		return tea.Batch(
+			func() tea.Msg { return LuaRanMsg{Run: run} },
+			func() tea.Msg { return ReportMsg{Title: "LUA", Body: luaRunReport(run)} },
+		)()
+	}
+}
+
+// newLuaRun resolves the script, keys and arguments of a run.
+func newLuaRun(history []LuaRun, script, keys, args string) (LuaRun, error) {
+	run := LuaRun{Index: len(history) + 1}
+	var err error
+	if run.Keys, err = dump.SplitArgs(keys); err != nil {
+		return run, fmt.Errorf("invalid keys: %w", err)
+	}
+	if run.Args, err = dump.SplitArgs(args); err != nil {
+		return run, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	trimmed := strings.TrimSpace(script)
+	switch {
+	case trimmed == "":
+		return run, errors.New("the script is empty")
+
+	case strings.HasPrefix(trimmed, "#") && !strings.Contains(trimmed, "\n"):
+		n, err := strconv.Atoi(trimmed[1:])
+		if err != nil || n < 1 || n > len(history) {
+			return run, fmt.Errorf("no run %s in the history (%d runs)", trimmed, len(history))
+		}
+		prev := history[n-1]
+		run.Script, run.Path = prev.Script, prev.Path
+		if keys == "" && args == "" {
+			run.Keys, run.Args = prev.Keys, prev.Args
+		}
+
+	case strings.HasSuffix(trimmed, ".lua") && !strings.Contains(trimmed, "\n"):
+		b, err := os.ReadFile(trimmed)
+		if err != nil {
+			return run, fmt.Errorf("failed to read script: %w", err)
+		}
+		run.Script, run.Path = string(b), trimmed
+
+	default:
+		run.Script = script
+	}
+	run.SHA = scriptSHA(run.Script)
+	return run, nil
+}
+
+// LuaScriptsExist reports which scripts of the history, and the given script if any,
+// are in the script cache of the server, with SCRIPT EXISTS.
+func LuaScriptsExist(ctx context.Context, src source.Source, history []LuaRun, script string) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+
+		// Digests with the first line of their script, in the order they were run
+		var shas []string
+		firstLines := make(map[string]string)
+		add := func(s string) {
+			sha := scriptSHA(s)
+			if _, ok := firstLines[sha]; !ok {
+				shas = append(shas, sha)
+				firstLines[sha], _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+			}
+		}
+		for _, r := range history {
+			add(r.Script)
+		}
+		if s := strings.TrimSpace(script); s != "" && !strings.HasPrefix(s, "#") && !strings.HasSuffix(s, ".lua") {
+			add(script)
+		}
+		if len(shas) == 0 {
+			return NewWarningMsg(infoid.New(), "No script to look for, run or write one first.", expiration)
+		}
+
+		exists, err := client.ScriptExists(ctx, shas...).Result()
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Script cache of %s\n\n", src.Name())
+		for i, sha := range shas {
+			state := "missing"
+			if exists[i] {
+				state = "cached"
+			}
+			fmt.Fprintf(&sb, "%s %-7s %s\n", sha, state, truncateArg(format.Printable(firstLines[sha])))
+		}
+		return ReportMsg{Title: "LUA SCRIPTS", Body: sb.String()}
+	}
+}
+
+// FlushLuaScripts removes every script from the script cache of the server, with SCRIPT FLUSH.
+func FlushLuaScripts(ctx context.Context, src source.Source) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if err := client.ScriptFlush(ctx).Err(); err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		log.Print("Flushed the script cache")
+		return NewInfoMsg(infoid.New(), "Script cache flushed, scripts are loaded again when run with EVALSHA.", expiration)
+	}
+}
+
+// LuaRunHistory reports the previous runs of the workbench, the latest first.
+func LuaRunHistory(history []LuaRun) tea.Cmd {
+	return func() tea.Msg {
+		if len(history) == 0 {
+			return NewWarningMsg(infoid.New(), "No script has run yet.", expiration)
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d runs, run one again with #N as the script\n", len(history))
+		runs := append([]LuaRun(nil), history...)
+		sort.SliceStable(runs, func(i, j int) bool { return runs[i].Index > runs[j].Index })
+		for _, r := range runs {
+			result, _, _ := strings.Cut(r.Reply, "\n")
+			if r.Err != nil {
+				result = "(error) " + r.Err.Error()
+			}
+			first, _, _ := strings.Cut(strings.TrimSpace(r.Script), "\n")
+			if r.Path != "" {
+				first = r.Path
+			}
+			fmt.Fprintf(&sb, "\n#%d %s %s %s, took %s\n", r.Index, r.At.Format(time.TimeOnly), strings.ToUpper(r.Mode), r.SHA[:8], r.Took.Round(time.Microsecond))
+			fmt.Fprintf(&sb, "   script: %s\n", truncateArg(format.Printable(first)))
+			fmt.Fprintf(&sb, "   keys:   %s\n", truncateArg(dump.InlineCommand(r.Keys)))
+			fmt.Fprintf(&sb, "   args:   %s\n", truncateArg(dump.InlineCommand(r.Args)))
+			fmt.Fprintf(&sb, "   reply:  %s\n", truncateArg(result))
+		}
+		return ReportMsg{Title: "LUA HISTORY", Body: sb.String()}
+	}
+}
+
+func luaRunReport(r LuaRun) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#%d %s %s", r.Index, strings.ToUpper(r.Mode), r.SHA)
+	if r.Loaded {
+		sb.WriteString(" (loaded with SCRIPT LOAD)")
+	}
+	fmt.Fprintf(&sb, "\nTook %s at %s\n", r.Took.Round(time.Microsecond), r.At.Format(time.TimeOnly))
+	if r.Path != "" {
+		fmt.Fprintf(&sb, "File: %s\n", r.Path)
+	}
+	fmt.Fprintf(&sb, "\nKEYS: %s\nARGV: %s\n\n", dump.InlineCommand(r.Keys), dump.InlineCommand(r.Args))
+	if r.Err != nil {
+		fmt.Fprintf(&sb, "(error) %v\n", r.Err)
+	} else {
+		sb.WriteString(r.Reply)
+	}
+	return sb.String()
+}
+
+// formatReply formats a reply the way redis-cli does, e.g. nested arrays as numbered items.
+func formatReply(v any) string {
+	var sb strings.Builder
+	writeReply(&sb, v, "")
+	return sb.String()
+}
+
+// writeReply writes a reply whose first line is already indented, indenting its other lines with indent.
+func writeReply(sb *strings.Builder, v any, indent string) {
+	switch v := v.(type) {
+	case nil:
+		sb.WriteString("(nil)\n")
+	case string:
+		sb.WriteString(strconv.Quote(v) + "\n")
+	case int64:
+		fmt.Fprintf(sb, "(integer) %d\n", v)
+	case float64:
+		fmt.Fprintf(sb, "(double) %s\n", strconv.FormatFloat(v, 'g', -1, 64))
+	case bool:
+		fmt.Fprintf(sb, "(%t)\n", v)
+	case error:
+		fmt.Fprintf(sb, "(error) %v\n", v)
+	case []any:
+		if len(v) == 0 {
+			sb.WriteString("(empty array)\n")
+			return
+		}
+		width := len(strconv.Itoa(len(v)))
+		for i, el := range v {
+			prefix := fmt.Sprintf("%*d) ", width, i+1)
+			if i > 0 {
+				sb.WriteString(indent)
+			}
+			sb.WriteString(prefix)
+			writeReply(sb, el, indent+strings.Repeat(" ", len(prefix)))
+		}
+	case map[any]any:
+		if len(v) == 0 {
+			sb.WriteString("(empty hash)\n")
+			return
+		}
+		// Maps are unordered, their keys are sorted for stable output
+		keys := make([]any, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		width := len(strconv.Itoa(len(v)))
+		for i, k := range keys {
+			prefix := fmt.Sprintf("%*d# ", width, i+1)
+			if i > 0 {
+				sb.WriteString(indent)
+			}
+			sb.WriteString(prefix)
+			key := strings.TrimSuffix(formatReply(k), "\n")
+			sb.WriteString(key + " => ")
+			writeReply(sb, v[k], indent+strings.Repeat(" ", len(prefix)+len(key)+4))
+		}
+	default:
+		fmt.Fprintf(sb, "%v\n", v)
+	}
+}
+
+func scriptSHA(script string) string {
+	sum := sha1.Sum([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/source"
+)
+
+// Names of the modules that red supports, as listed by MODULE LIST.
+const (
+	ModuleJSON       = "rejson"
+	ModuleSearch     = "search"
+	ModuleTimeSeries = "timeseries"
+	ModuleBloom      = "bf"
+)
+
+// ModulesLoadedMsg is sent with the modules loaded in the server, by lowercase name.
+type ModulesLoadedMsg struct {
+	Modules map[string]bool
+}
+
+func (m ModulesLoadedMsg) String() string {
+	return fmt.Sprintf("modules_loaded - modules: %d", len(m.Modules))
+}
+
+// GetModules lists the modules loaded in the server with MODULE LIST, so that the
+// features relying on them only show up when they are available. File sources and
+// servers where MODULE LIST is not allowed have no modules.
+func GetModules(ctx context.Context, src source.Source) tea.Cmd {
+	return func() tea.Msg {
+		modules := make(map[string]bool)
+		client := src.Client()
+		if client == nil {
+			return ModulesLoadedMsg{Modules: modules}
+		}
+		reply, err := client.Do(ctx, "MODULE", "LIST").Slice()
+		if err != nil {
+			log.Printf("Failed to list modules: %v", err)
+			return ModulesLoadedMsg{Modules: modules}
+		}
+		for _, m := range reply {
+			if name := moduleName(m); name != "" {
+				modules[strings.ToLower(name)] = true
+			}
+		}
+		log.Printf("Modules loaded in %s: %v", src.Name(), modules)
+		return ModulesLoadedMsg{Modules: modules}
+	}
+}
+
+// moduleName returns the name of a module in the reply of MODULE LIST, which is
+// a list of fields and values in RESP2, and a map in RESP3.
+func moduleName(m any) string {
+	switch m := m.(type) {
+	case []any:
+		for i := 0; i+1 < len(m); i += 2 {
+			if fmt.Sprint(m[i]) == "name" {
+				return fmt.Sprint(m[i+1])
+			}
+		}
+	case map[any]any:
+		if name, ok := m["name"]; ok {
+			return fmt.Sprint(name)
+		}
+	case map[string]any:
+		if name, ok := m["name"]; ok {
+			return fmt.Sprint(name)
+		}
+	}
+	return ""
+}
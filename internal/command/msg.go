@@ -4,7 +4,8 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"github.com/hirotake111/redisclient/internal/source"
+	"github.com/hirotake111/redisclient/internal/values"
 )
 
 type MsgWithKind interface {
@@ -12,7 +13,8 @@ type MsgWithKind interface {
 }
 
 type KeysUpdatedMsg struct {
-	Keys []string
+	Keys   []string
+	Polled bool // Whether the keys were fetched by the periodic refresh
 }
 
 func (KeysUpdatedMsg) String() string {
@@ -20,20 +22,26 @@ func (KeysUpdatedMsg) String() string {
 }
 
 type ValueUpdatedMsg struct {
-	NewValue string // The new value for the key
-	TTL      int64  // Time to live for the key, if applicable
+	Key        string             // The key the value belongs to
+	NewValue   string             // The new value for the key, raw bytes for strings
+	Type       string             // Redis type of the key
+	Collection *values.Collection // Loaded elements for hashes, lists, sets and sorted sets
+	TTL        int64              // Time to live for the key, if applicable
+	FetchedAt  time.Time          // Time at which the value was fetched from Redis
+	Cached     bool               // Whether the value was served from the cache
 }
 
 func (v ValueUpdatedMsg) String() string {
 	return fmt.Sprintf("value_updated (TTL: %d)", v.TTL)
 }
 
-type NewRedisClientMsg struct {
-	Redis *redis.Client
+// NewSourceMsg is sent when another database of the source is selected.
+type NewSourceMsg struct {
+	Source source.Source
 }
 
-func (n NewRedisClientMsg) String() string {
-	return "new_redis_client"
+func (n NewSourceMsg) String() string {
+	return fmt.Sprintf("new_source - %s, db: %d", n.Source.Name(), n.Source.DB())
 }
 
 type KeyDeletedMsg struct {
@@ -47,6 +55,14 @@ func (k KeyDeletedMsg) String() string {
 
 type CopySuccessMsg struct{}
 
+type CopyRequestedMsg struct {
+	Value string
+}
+
+func (c CopyRequestedMsg) String() string {
+	return fmt.Sprintf("copy_requested - bytes: %d", len(c.Value))
+}
+
 type HighlightedKeyUpdatedMsg struct {
 	Key string
 }
@@ -62,3 +78,68 @@ type TickMsg struct {
 func (t TickMsg) String() string {
 	return fmt.Sprintf("tick - time: %s", t.Time.String())
 }
+
+type KeyMetricsUpdatedMsg struct {
+	Keys      []string         // Keys the metrics were fetched for
+	Requested string           // Metric that was requested, e.g. MetricAccess
+	Metric    string           // Metric that was actually fetched
+	Metrics   map[string]int64 // Metric value per key, -1 if unavailable
+}
+
+func (k KeyMetricsUpdatedMsg) String() string {
+	return fmt.Sprintf("key_metrics_updated - metric: %s, keys: %d", k.Metric, len(k.Keys))
+}
+
+// ValuePageRequestedMsg asks for the next page of the collection displayed in the viewport.
+type ValuePageRequestedMsg struct {
+	Key    string
+	Type   string
+	Cursor uint64
+}
+
+func (v ValuePageRequestedMsg) String() string {
+	return fmt.Sprintf("value_page_requested - key: %s, cursor: %d", v.Key, v.Cursor)
+}
+
+type ValuePageLoadedMsg struct {
+	Key  string
+	Page values.Collection
+}
+
+func (v ValuePageLoadedMsg) String() string {
+	return fmt.Sprintf("value_page_loaded - key: %s, elements: %d", v.Key, len(v.Page.Elements))
+}
+
+type ExportRequestedMsg struct {
+	Keys   []string // Keys to export, nil for the whole database
+	Format string
+}
+
+func (e ExportRequestedMsg) String() string {
+	return fmt.Sprintf("export_requested - keys: %d, format: %s", len(e.Keys), e.Format)
+}
+
+type SyncRequestedMsg struct {
+	Keys []string // Keys to copy, nil for the whole database
+}
+
+func (s SyncRequestedMsg) String() string {
+	return fmt.Sprintf("sync_requested - keys: %d", len(s.Keys))
+}
+
+// ReportMsg displays a plain text report, e.g. the result of an import, in the viewport.
+type ReportMsg struct {
+	Title  string
+	Body   string
+	Syntax string // Language of the body to highlight, e.g. SyntaxLua, none if empty
+}
+
+// Syntaxes of reports.
+const (
+	SyntaxLua    = "lua"    // Lua code, highlighted
+	SyntaxStyled = "styled" // Text already styled, e.g. a chart, displayed as is
+)
+
+func (r ReportMsg) String() string {
+	return fmt.Sprintf("report - title: %s", r.Title)
+}
@@ -0,0 +1,33 @@
+package command
+
+import (
+	"context"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/source"
+	"github.com/hirotake111/redisclient/internal/values"
+)
+
+// GetValuePage fetches the page of a hash, list, set or sorted set starting at cursor.
+func GetValuePage(ctx context.Context, src source.Source, key, t string, cursor uint64, pageSize int64) tea.Cmd {
+	return func() tea.Msg {
+		page, err := fetchPage(ctx, src, key, t, cursor, pageSize)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		return ValuePageLoadedMsg{Key: key, Page: page}
+	}
+}
+
+// fetchPage fetches about pageSize elements starting at cursor.
+func fetchPage(ctx context.Context, src source.Source, key, t string, cursor uint64, pageSize int64) (values.Collection, error) {
+	log.Printf("Fetching page of %s \"%s\" at cursor %d", t, key, cursor)
+	page, err := src.Page(ctx, key, t, cursor, pageSize)
+	if err != nil {
+		return page, err
+	}
+	log.Printf("Fetched %d of %d elements of \"%s\"", len(page.Elements), page.Total, key)
+	return page, nil
+}
@@ -0,0 +1,185 @@
+package command
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"strings"
+	"text/tabwriter"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/dump"
+	"github.com/hirotake111/redisclient/internal/format"
+	"github.com/hirotake111/redisclient/internal/source"
+)
+
+// Types of the probabilistic data structures of RedisBloom, as returned by TYPE.
+const (
+	TypeBloom    = "MBbloom--"
+	TypeCuckoo   = "MBbloomCF"
+	TypeCountMin = "CMSk-TYPE"
+	TypeTopK     = "TopK-TYPE"
+)
+
+// TypeHyperLogLog is the type of the strings holding a HyperLogLog, which TYPE reports as strings.
+const TypeHyperLogLog = "hyperloglog"
+
+// hllHeaderSize is the size of the header of HyperLogLogs: "HYLL", the encoding,
+// 3 unused bytes and the cached cardinality.
+const hllHeaderSize = 16
+
+// isHyperLogLog reports whether a string holds a HyperLogLog.
+func isHyperLogLog(s string) bool {
+	return len(s) >= hllHeaderSize && strings.HasPrefix(s, "HYLL")
+}
+
+// hyperLogLogValue describes a HyperLogLog, with its cardinality estimated by PFCOUNT.
+func hyperLogLogValue(ctx context.Context, src source.Source, key, value string) string {
+	var sb strings.Builder
+	sb.WriteString("HyperLogLog\n\n")
+	if client := src.Client(); client != nil {
+		if n, err := client.PFCount(ctx, key).Result(); err != nil {
+			fmt.Fprintf(&sb, "Cardinality: (error) %v\n", err)
+		} else {
+			fmt.Fprintf(&sb, "Cardinality: %d (PFCOUNT)\n", n)
+		}
+	}
+	// The cached cardinality is little endian, its most significant bit tells that it is stale
+	cached := binary.LittleEndian.Uint64([]byte(value[8:hllHeaderSize]))
+	if cached&(1<<63) != 0 {
+		sb.WriteString("Cached:      stale, computed again by the next PFCOUNT\n")
+	} else {
+		fmt.Fprintf(&sb, "Cached:      %d\n", cached)
+	}
+	encoding := "dense, 16384 registers of 6 bits"
+	if value[4] == 1 {
+		encoding = "sparse, converted to dense when it outgrows hll-sparse-max-bytes"
+	}
+	fmt.Fprintf(&sb, "Encoding:    %s\n", encoding)
+	fmt.Fprintf(&sb, "Size:        %d bytes\n\nHeader\n%s", len(value), format.HexDump([]byte(value[:hllHeaderSize])))
+	return sb.String()
+}
+
+// probabilisticValue describes a Bloom or Cuckoo filter, a Count-Min sketch or a Top-K
+// with their INFO command, and lists the items of a Top-K.
+func probabilisticValue(ctx context.Context, src source.Source, key, t string) (string, error) {
+	client := src.Client()
+	if client == nil {
+		return "", fmt.Errorf("%s can't read %s keys", src.Name(), t)
+	}
+	name, prefix := probabilisticName(t)
+	reply, err := client.Do(ctx, prefix+".INFO", key).Result()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(name + "\n\n")
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	for _, f := range replyFields(reply) {
+		fmt.Fprintf(tw, "%s\t%s\n", f[0], f[1])
+	}
+	_ = tw.Flush()
+
+	if t == TypeTopK {
+		items, err := client.Do(ctx, "TOPK.LIST", key, "WITHCOUNT").Slice()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "\nTop items\n")
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		for i := 0; i+1 < len(items); i += 2 {
+			fmt.Fprintf(tw, "  %d\t%s\t%s\n", i/2+1, oneLine(replyString(items[i])), replyString(items[i+1]))
+		}
+		_ = tw.Flush()
+	}
+	sb.WriteString("\nM checks items against it\n")
+	return sb.String(), nil
+}
+
+// CheckItems checks items, separated by spaces and quoted like in redis-cli, against a
+// Bloom or Cuckoo filter (BF.MEXISTS, CF.MEXISTS), a Count-Min sketch (CMS.QUERY) or
+// a Top-K (TOPK.QUERY).
+func CheckItems(ctx context.Context, src source.Source, key, items string) tea.Cmd {
+	return func() tea.Msg {
+		client := src.Client()
+		if client == nil {
+			return NewWarningMsg(infoid.New(), fmt.Sprintf("%s can't check items, RedisBloom needs a server.", src.Name()), expiration)
+		}
+		list, err := dump.SplitArgs(items)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("invalid items: %w", err), expiration)
+		}
+		if len(list) == 0 {
+			return NewWarningMsg(infoid.New(), "Enter the items to check.", expiration)
+		}
+		t, err := src.Type(ctx, key)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+
+		var cmd string
+		var describe func(v any) string
+		switch t {
+		case TypeBloom, TypeCuckoo:
+			_, prefix := probabilisticName(t)
+			cmd = prefix + ".MEXISTS"
+			describe = func(v any) string {
+				if replyTruthy(v) {
+					return "maybe present"
+				}
+				return "absent"
+			}
+		case TypeCountMin:
+			cmd = "CMS.QUERY"
+			describe = func(v any) string { return "counted at most " + replyString(v) + " times" }
+		case TypeTopK:
+			cmd = "TOPK.QUERY"
+			describe = func(v any) string {
+				if replyTruthy(v) {
+					return "in the top items"
+				}
+				return "not in the top items"
+			}
+		default:
+			return NewWarningMsg(infoid.New(), fmt.Sprintf("%s is a %s, not a Bloom or Cuckoo filter, Count-Min sketch or Top-K.", key, t), expiration)
+		}
+
+		args := []any{cmd, key}
+		for _, it := range list {
+			args = append(args, it)
+		}
+		res, err := client.Do(ctx, args...).Slice()
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		log.Printf("Checked %d items against %s with %s", len(list), key, cmd)
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s %s\n\n", cmd, key)
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		for i, it := range list {
+			if i < len(res) {
+				fmt.Fprintf(tw, "%s\t%s\n", oneLine(it), describe(res[i]))
+			}
+		}
+		_ = tw.Flush()
+		return ReportMsg{Title: "CHECK " + key, Body: sb.String()}
+	}
+}
+
+// probabilisticName returns the name of a RedisBloom type, and the prefix of its commands.
+func probabilisticName(t string) (string, string) {
+	switch t {
+	case TypeCuckoo:
+		return "Cuckoo filter", "CF"
+	case TypeCountMin:
+		return "Count-Min sketch", "CMS"
+	case TypeTopK:
+		return "Top-K", "TOPK"
+	default:
+		return "Bloom filter", "BF"
+	}
+}
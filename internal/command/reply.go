@@ -0,0 +1,77 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// replyFields returns the fields and values of a list of fields and values, or of a map.
+func replyFields(v any) [][2]string {
+	var fields [][2]string
+	switch v := v.(type) {
+	case []any:
+		for i := 0; i+1 < len(v); i += 2 {
+			fields = append(fields, [2]string{replyString(v[i]), replyString(v[i+1])})
+		}
+	case map[any]any:
+		for k, val := range v {
+			fields = append(fields, [2]string{replyString(k), replyString(val)})
+		}
+		sort.Slice(fields, func(i, j int) bool { return fields[i][0] < fields[j][0] })
+	}
+	return fields
+}
+
+// replyMap returns the entries of a map in RESP3, or of a list of fields and values in RESP2.
+func replyMap(v any) map[string]any {
+	m := make(map[string]any)
+	switch v := v.(type) {
+	case []any:
+		for i := 0; i+1 < len(v); i += 2 {
+			m[replyString(v[i])] = v[i+1]
+		}
+	case map[any]any:
+		for k, val := range v {
+			m[replyString(k)] = val
+		}
+	case map[string]any:
+		return v
+	}
+	return m
+}
+
+// replyString formats a reply on a single line, e.g. lists as [a b].
+func replyString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []any:
+		parts := make([]string, len(v))
+		for i, el := range v {
+			parts[i] = replyString(el)
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// replyTruthy reports whether a reply is true, a boolean in RESP3 or 1 in RESP2.
+func replyTruthy(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case int64:
+		return v == 1
+	default:
+		return replyString(v) == "1"
+	}
+}
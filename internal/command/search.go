@@ -0,0 +1,433 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/dump"
+	"github.com/hirotake111/redisclient/internal/source"
+)
+
+// Actions of the search screen.
+const (
+	SearchIndexes   = "indexes"
+	SearchInfo      = "info"
+	SearchQuery     = "search"
+	SearchAggregate = "aggregate"
+	SearchExplain   = "explain"
+	SearchHistory   = "history"
+)
+
+// SearchActions lists every action, e.g. for a selector.
+var SearchActions = []string{SearchQuery, SearchAggregate, SearchExplain, SearchInfo, SearchIndexes, SearchHistory}
+
+// SearchPageSize is the number of results displayed at a time.
+const SearchPageSize = 20
+
+// Search is a query run with FT.SEARCH or FT.AGGREGATE, kept in the history of the search screen.
+type Search struct {
+	Index   string
+	Query   string
+	Options string // Other arguments, e.g. SORTBY price DESC
+	Mode    string // SearchQuery or SearchAggregate
+	Offset  int    // Index of the first result of the page
+}
+
+// SearchedMsg is sent with a page of results. Keys are the documents found by FT.SEARCH.
+type SearchedMsg struct {
+	Search Search
+	Keys   []string
+	Total  int64
+}
+
+func (s SearchedMsg) String() string {
+	return fmt.Sprintf("searched - index: %s, offset: %d, hits: %d, total: %d", s.Search.Index, s.Search.Offset, len(s.Keys), s.Total)
+}
+
+// ResolveSearch returns the search of the history that query refers to with #N,
+// or a new search.
+func ResolveSearch(history []Search, s Search) (Search, error) {
+	q := strings.TrimSpace(s.Query)
+	if !strings.HasPrefix(q, "#") {
+		return s, nil
+	}
+	n, err := strconv.Atoi(q[1:])
+	if err != nil || n < 1 || n > len(history) {
+		return s, fmt.Errorf("no query %s in the history (%d queries)", q, len(history))
+	}
+	prev := history[n-1]
+	prev.Offset = 0
+	return prev, nil
+}
+
+// RunSearch runs a page of a search with FT.SEARCH or FT.AGGREGATE, and reports its results.
+func RunSearch(ctx context.Context, src source.Source, s Search) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if s.Index == "" {
+			return NewWarningMsg(infoid.New(), "Enter the name of the index to search.", expiration)
+		}
+		if s.Query == "" {
+			s.Query = "*"
+		}
+		opts, err := dump.SplitArgs(s.Options)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), fmt.Errorf("invalid options: %w", err), expiration)
+		}
+
+		cmd := "FT.SEARCH"
+		if s.Mode == SearchAggregate {
+			cmd = "FT.AGGREGATE"
+		}
+		layout := searchLayoutOf(opts)
+		if s.Mode != SearchAggregate && layout.limit {
+			return NewWarningMsg(infoid.New(), "LIMIT is set by paging, use ] and [ to go through the results.", expiration)
+		}
+		args := []any{cmd, s.Index, s.Query}
+		for _, o := range opts {
+			args = append(args, o)
+		}
+		if s.Mode != SearchAggregate && !layout.scores {
+			args = append(args, "WITHSCORES")
+		}
+		args = append(args, "LIMIT", s.Offset, SearchPageSize)
+
+		log.Printf("Running %s on index %s (offset: %d)", cmd, s.Index, s.Offset)
+		start := time.Now()
+		reply, err := client.Do(ctx, args...).Result()
+		took := time.Since(start)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+
+		var total int64
+		var hits []searchHit
+		if s.Mode == SearchAggregate {
+			total, hits = parseAggregate(reply)
+		} else {
+			total, hits = parseSearch(reply, layout)
+		}
+		var keys []string
+		for _, h := range hits {
+			if h.key != "" {
+				keys = append(keys, h.key)
+			}
+		}
+
+		var sb strings.Builder
+		line := fmt.Sprintf("%s %s %s %s", cmd, s.Index, dump.InlineCommand([]string{s.Query}), dump.InlineCommand(opts))
+		sb.WriteString(strings.TrimSpace(line) + "\n")
+		switch {
+		case len(hits) == 0:
+			fmt.Fprintf(&sb, "%d results, none from %d, took %s\n", total, s.Offset+1, took.Round(time.Microsecond))
+		default:
+			fmt.Fprintf(&sb, "%d results, %d-%d, took %s (] next page, [ previous page)\n", total, s.Offset+1, s.Offset+len(hits), took.Round(time.Microsecond))
+		}
+		if len(keys) > 0 {
+			sb.WriteString("The key list shows the documents found, r shows every key again\n")
+		}
+		for i, h := range hits {
+			sb.WriteString("\n")
+			fmt.Fprintf(&sb, "%d) %s", s.Offset+i+1, oneLine(h.key))
+			if h.score != "" {
+				fmt.Fprintf(&sb, " (score %s)", h.score)
+			}
+			sb.WriteString("\n")
+			for _, f := range h.fields {
+				fmt.Fprintf(&sb, "   %s: %s\n", oneLine(f[0]), truncateArg(oneLine(f[1])))
+			}
+		}
+
+		return tea.Batch(
+			func() tea.Msg { return SearchedMsg{Search: s, Keys: keys, Total: total} },
+			func() tea.Msg { return ReportMsg{Title: cmd, Body: sb.String()} },
+		)()
+	}
+}
+
+// ExplainSearch reports how a query is parsed, with FT.EXPLAIN.
+func ExplainSearch(ctx context.Context, src source.Source, index, query string) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if index == "" {
+			return NewWarningMsg(infoid.New(), "Enter the name of the index to search.", expiration)
+		}
+		if query == "" {
+			query = "*"
+		}
+		plan, err := client.Do(ctx, "FT.EXPLAIN", index, query).Text()
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		body := fmt.Sprintf("FT.EXPLAIN %s %s\n\n%s", index, dump.InlineCommand([]string{query}), plan)
+		return ReportMsg{Title: "FT.EXPLAIN", Body: body}
+	}
+}
+
+// SearchIndexInfo reports the definition, schema, size and indexing status of an index, with FT.INFO.
+func SearchIndexInfo(ctx context.Context, src source.Source, index string) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if index == "" {
+			return NewWarningMsg(infoid.New(), "Enter the name of the index.", expiration)
+		}
+		reply, err := client.Do(ctx, "FT.INFO", index).Result()
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		info := replyMap(reply)
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Index %s\n", index)
+		def := replyMap(info["index_definition"])
+		fmt.Fprintf(&sb, "\nDefinition\n  key type: %s\n  prefixes: %s\n", replyString(def["key_type"]), replyString(def["prefixes"]))
+		if f := replyString(def["filter"]); f != "" {
+			fmt.Fprintf(&sb, "  filter:   %s\n", f)
+		}
+		if opts := replyString(info["index_options"]); opts != "" && opts != "[]" {
+			fmt.Fprintf(&sb, "  options:  %s\n", opts)
+		}
+
+		sb.WriteString("\nSchema\n")
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  FIELD\tAS\tTYPE\tOPTIONS")
+		attrs, _ := info["attributes"].([]any)
+		for _, a := range attrs {
+			fmt.Fprintln(tw, "  "+strings.Join(attributeRow(a), "\t"))
+		}
+		_ = tw.Flush()
+
+		sb.WriteString("\nStatus\n")
+		tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		for _, f := range []string{
+			"num_docs", "max_doc_id", "num_terms", "num_records", "indexing", "percent_indexed",
+			"hash_indexing_failures", "total_indexing_time", "inverted_sz_mb", "doc_table_size_mb",
+			"total_index_memory_sz_mb", "number_of_uses",
+		} {
+			if v, ok := info[f]; ok {
+				fmt.Fprintf(tw, "  %s\t%s\n", f, replyString(v))
+			}
+		}
+		_ = tw.Flush()
+		if errs := replyMap(info["Index Errors"]); len(errs) > 0 {
+			if e := replyString(errs["last indexing error"]); e != "" && e != "N/A" {
+				fmt.Fprintf(&sb, "\nLast indexing error on %s: %s\n", replyString(errs["last indexing error key"]), e)
+			}
+		}
+		return ReportMsg{Title: "FT.INFO", Body: sb.String()}
+	}
+}
+
+// ListSearchIndexes lists the indexes with FT._LIST, with their number of documents and indexing status.
+func ListSearchIndexes(ctx context.Context, src source.Source) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		indexes, err := client.Do(ctx, "FT._LIST").StringSlice()
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if len(indexes) == 0 {
+			return NewWarningMsg(infoid.New(), "No search index on "+src.Name()+".", expiration)
+		}
+		sort.Strings(indexes)
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d indexes on %s\n\n", len(indexes), src.Name())
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "INDEX\tDOCS\tINDEXED\tFAILURES\tPREFIXES")
+		for _, index := range indexes {
+			reply, err := client.Do(ctx, "FT.INFO", index).Result()
+			if err != nil {
+				fmt.Fprintf(tw, "%s\t(error) %v\n", index, err)
+				continue
+			}
+			info := replyMap(reply)
+			indexed := replyString(info["percent_indexed"])
+			if f, err := strconv.ParseFloat(indexed, 64); err == nil {
+				indexed = strconv.FormatFloat(f*100, 'f', 0, 64) + "%"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", index, replyString(info["num_docs"]), indexed,
+				replyString(info["hash_indexing_failures"]), replyString(replyMap(info["index_definition"])["prefixes"]))
+		}
+		_ = tw.Flush()
+		return ReportMsg{Title: "SEARCH INDEXES", Body: sb.String()}
+	}
+}
+
+// SearchQueryHistory reports the previous searches, the latest first.
+func SearchQueryHistory(history []Search) tea.Cmd {
+	return func() tea.Msg {
+		if len(history) == 0 {
+			return NewWarningMsg(infoid.New(), "No search has run yet.", expiration)
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d searches, run one again with #N as the query\n\n", len(history))
+		for i := len(history) - 1; i >= 0; i-- {
+			s := history[i]
+			cmd := "FT.SEARCH"
+			if s.Mode == SearchAggregate {
+				cmd = "FT.AGGREGATE"
+			}
+			line := fmt.Sprintf("%s %s %s %s", cmd, s.Index, dump.InlineCommand([]string{s.Query}), s.Options)
+			fmt.Fprintf(&sb, "#%d %s\n", i+1, truncateArg(oneLine(strings.TrimSpace(line))))
+		}
+		return ReportMsg{Title: "SEARCH HISTORY", Body: sb.String()}
+	}
+}
+
+// searchHit is a document found by FT.SEARCH, or a row of FT.AGGREGATE without key.
+type searchHit struct {
+	key    string
+	score  string
+	fields [][2]string
+}
+
+// searchLayout tells the elements of each document in the RESP2 reply of FT.SEARCH,
+// which depend on the options.
+type searchLayout struct {
+	scores   bool // WITHSCORES is among the options, it is added otherwise
+	payloads bool // WITHPAYLOADS
+	sortKeys bool // WITHSORTKEYS
+	content  bool // Fields are returned, unless NOCONTENT or RETURN 0
+	limit    bool // LIMIT is among the options
+}
+
+func searchLayoutOf(opts []string) searchLayout {
+	l := searchLayout{content: true}
+	for i, o := range opts {
+		switch strings.ToUpper(o) {
+		case "WITHSCORES":
+			l.scores = true
+		case "WITHPAYLOADS":
+			l.payloads = true
+		case "WITHSORTKEYS":
+			l.sortKeys = true
+		case "NOCONTENT":
+			l.content = false
+		case "RETURN":
+			if i+1 < len(opts) && opts[i+1] == "0" {
+				l.content = false
+			}
+		case "LIMIT":
+			l.limit = true
+		}
+	}
+	return l
+}
+
+// parseSearch parses the reply of FT.SEARCH ... WITHSCORES, which is [total, key, score, [field, value...], ...]
+// in RESP2, with a payload and a sort key before the fields depending on the layout, and a map of
+// total_results and results in RESP3.
+func parseSearch(reply any, layout searchLayout) (int64, []searchHit) {
+	if l, ok := reply.([]any); ok {
+		if len(l) == 0 {
+			return 0, nil
+		}
+		total, _ := l[0].(int64)
+		stride := 2 // Key and score
+		for _, b := range []bool{layout.payloads, layout.sortKeys, layout.content} {
+			if b {
+				stride++
+			}
+		}
+		var hits []searchHit
+		for i := 1; i < len(l); i += stride {
+			h := searchHit{key: replyString(l[i])}
+			if i+1 < len(l) {
+				h.score = replyString(l[i+1])
+			}
+			if j := i + stride - 1; layout.content && j < len(l) {
+				h.fields = replyFields(l[j])
+			}
+			hits = append(hits, h)
+		}
+		return total, hits
+	}
+	return parseResults(reply)
+}
+
+// parseAggregate parses the reply of FT.AGGREGATE, which is [total, [field, value...], ...]
+// in RESP2, and a map of total_results and results in RESP3.
+func parseAggregate(reply any) (int64, []searchHit) {
+	if l, ok := reply.([]any); ok {
+		if len(l) == 0 {
+			return 0, nil
+		}
+		total, _ := l[0].(int64)
+		hits := make([]searchHit, 0, len(l)-1)
+		for _, row := range l[1:] {
+			hits = append(hits, searchHit{fields: replyFields(row)})
+		}
+		return total, hits
+	}
+	return parseResults(reply)
+}
+
+// parseResults parses the RESP3 replies of FT.SEARCH and FT.AGGREGATE.
+func parseResults(reply any) (int64, []searchHit) {
+	m := replyMap(reply)
+	total, _ := m["total_results"].(int64)
+	results, _ := m["results"].([]any)
+	hits := make([]searchHit, 0, len(results))
+	for _, r := range results {
+		rm := replyMap(r)
+		h := searchHit{key: replyString(rm["id"]), fields: replyFields(rm["extra_attributes"])}
+		if s, ok := rm["score"]; ok {
+			h.score = replyString(s)
+		}
+		hits = append(hits, h)
+	}
+	return total, hits
+}
+
+// attributeRow returns the identifier, name, type and options of an attribute of FT.INFO,
+// which is a list of fields and values followed by flags, e.g. SORTABLE, in RESP2, and a map in RESP3.
+func attributeRow(v any) []string {
+	known := map[string]bool{"identifier": true, "attribute": true, "type": true}
+	var opts []string
+	m := make(map[string]string)
+	switch v := v.(type) {
+	case []any:
+		for i := 0; i < len(v); i++ {
+			name := replyString(v[i])
+			if known[name] && i+1 < len(v) {
+				m[name] = replyString(v[i+1])
+				i++
+				continue
+			}
+			opts = append(opts, name)
+		}
+	default:
+		for k, val := range replyMap(v) {
+			if known[k] {
+				m[k] = replyString(val)
+			} else if k == "flags" {
+				opts = append(opts, strings.Trim(replyString(val), "[]"))
+			} else {
+				opts = append(opts, k+" "+replyString(val))
+			}
+		}
+		sort.Strings(opts)
+	}
+	return []string{m["identifier"], m["attribute"], m["type"], strings.TrimSpace(strings.Join(opts, " "))}
+}
@@ -0,0 +1,25 @@
+package command
+
+import (
+	"context"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/source"
+)
+
+// GetKeyMetrics fetches the given metric (one of the source.Metric constants) for every key.
+// Keys that no longer exist or can't be measured get a metric of -1.
+func GetKeyMetrics(ctx context.Context, src source.Source, keys []string, metric string) tea.Cmd {
+	return func() tea.Msg {
+		log.Printf("Fetching metric \"%s\" for %d keys", metric, len(keys))
+		fetched, metrics, err := src.Metrics(ctx, keys, metric)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+
+		log.Printf("Fetched metric \"%s\" for %d keys", fetched, len(keys))
+		return KeyMetricsUpdatedMsg{Keys: keys, Requested: metric, Metric: fetched, Metrics: metrics}
+	}
+}
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/cache"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/dump"
+	"github.com/hirotake111/redisclient/internal/source"
+	"github.com/redis/go-redis/v9"
+)
+
+const syncBatchSize = 100
+
+// RequestSync asks the model to copy keys, or the whole database if keys is nil, to another database.
+func RequestSync(keys []string) tea.Cmd {
+	return func() tea.Msg {
+		return SyncRequestedMsg{Keys: keys}
+	}
+}
+
+// Sync copies keys, or every key of the database if keys is nil, to target: another
+// database given its index or another server given its profile or URL (see openTarget).
+// Keys are copied with DUMP and RESTORE, or MIGRATE with dump.MethodAuto, and from files
+// with the commands recreating them. The report lists the keys that failed.
+func Sync(ctx context.Context, src source.Source, c *cache.ValueCache, keys []string, target string, policy dump.Policy, method string) tea.Cmd {
+	return runTask(ctx, "Syncing", func(ctx context.Context, report Reporter) tea.Msg {
+		dst, err := openTarget(ctx, src, target)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		defer CloseTarget(src, dst)
+		if dst.Name() == src.Name() && dst.DB() == src.DB() {
+			return NewErrorMsg(infoid.New(), errors.New("the keys can't be copied to the database they are in"), expiration)
+		}
+		client, err := writableClient(dst)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		log.Printf("Syncing keys from %s to %s (policy: %s, method: %s)", sideName(src), sideName(dst), policy, method)
+
+		if keys == nil {
+			if keys, err = scanKeys(ctx, src); err != nil {
+				return NewErrorMsg(infoid.New(), fmt.Errorf("failed to list keys: %w", err), expiration)
+			}
+		}
+		copyBatch := syncBatch(src, client, policy, method)
+		var results []dump.Result
+		for start := 0; start < len(keys); start += syncBatchSize {
+			res, err := copyBatch(ctx, keys[start:min(start+syncBatchSize, len(keys))])
+			if err != nil {
+				return NewErrorMsg(infoid.New(), err, expiration)
+			}
+			results = append(results, res...)
+			report(int64(len(results)), int64(len(keys)))
+		}
+
+		if dst.Name() == src.Name() {
+			// Another database of the same server, whose values may be cached
+			c.Purge()
+		}
+		var failed []dump.Result
+		for _, r := range results {
+			if r.Action == dump.ActionFail {
+				failed = append(failed, r)
+			}
+		}
+		summary := "Sync: " + countActions(results, "no keys")
+		log.Print(summary)
+		header := fmt.Sprintf("%s → %s", sideName(src), sideName(dst))
+		if len(failed) == 0 {
+			header += "\nNo key failed."
+		}
+		body := resultReport(header, summary, failed)
+		if len(failed) > 0 {
+			return tea.Batch(
+				func() tea.Msg { return ReportMsg{Title: "SYNC REPORT", Body: body} },
+				NewWarningInfoCmd(infoid.New(), summary, expiration),
+			)()
+		}
+		return tea.Batch(
+			func() tea.Msg { return ReportMsg{Title: "SYNC REPORT", Body: body} },
+			NewInfoInfoCmd(infoid.New(), summary, expiration),
+		)()
+	})
+}
+
+// syncBatch returns the function copying a batch of keys from src to client.
+func syncBatch(src source.Source, client *redis.Client, policy dump.Policy, method string) func(context.Context, []string) ([]dump.Result, error) {
+	if srcClient := src.Client(); srcClient != nil {
+		return dump.NewSyncer(srcClient, client, policy, method).Sync
+	}
+
+	// Files have no DUMP payloads, their keys are recreated from their values
+	im := dump.NewImporter(client, policy, false)
+	return func(ctx context.Context, keys []string) ([]dump.Result, error) {
+		var results []dump.Result
+		var recs []dump.Record
+		for _, k := range keys {
+			rec, err := dump.FetchSource(ctx, src, k)
+			if err != nil {
+				results = append(results, dump.Result{Key: dump.Bytes(k), Action: dump.ActionFail, Err: err})
+				continue
+			}
+			recs = append(recs, rec)
+		}
+		if len(recs) == 0 {
+			return results, nil
+		}
+		res, _, err := im.Import(ctx, recs)
+		return append(results, res...), err
+	}
+}
@@ -0,0 +1,81 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+)
+
+// Reporter reports the progress of a background task.
+type Reporter func(done, total int64)
+
+// TaskProgressMsg is sent while a background task is running.
+// The model must run Next to keep receiving the task's messages.
+type TaskProgressMsg struct {
+	Task   string // Human readable name of the task, e.g. "Exporting"
+	Done   int64
+	Total  int64 // 0 if unknown
+	Next   tea.Cmd
+	Cancel context.CancelFunc // Stops the task, which still sends its result
+}
+
+func (t TaskProgressMsg) String() string {
+	return fmt.Sprintf("task_progress - task: %s, done: %d, total: %d", t.Task, t.Done, t.Total)
+}
+
+// TaskFinishedMsg is sent when a background task is over. Result is the message the task returned.
+type TaskFinishedMsg struct {
+	Task   string
+	Result tea.Msg
+}
+
+func (t TaskFinishedMsg) String() string {
+	return fmt.Sprintf("task_finished - task: %s", t.Task)
+}
+
+// runTask runs fn in the background, relaying its progress as TaskProgressMsg
+// and its result as TaskFinishedMsg. The context passed to fn is cancelled by
+// the Cancel function of the progress messages.
+func runTask(ctx context.Context, name string, fn func(ctx context.Context, report Reporter) tea.Msg) tea.Cmd {
+	ctx, cancel := context.WithCancel(ctx)
+	ch := make(chan tea.Msg, 1)
+	// The task is cancellable as soon as it starts
+	ch <- TaskProgressMsg{Task: name, Cancel: cancel}
+	go func() {
+		defer close(ch)
+		defer cancel()
+		report := func(done, total int64) {
+			select {
+			case ch <- TaskProgressMsg{Task: name, Done: done, Total: total, Cancel: cancel}:
+			default: // Drop progress updates while the previous one hasn't been displayed yet
+			}
+		}
+		result := fn(ctx, report)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			// Errors caused by the cancellation aren't worth reporting
+			log.Printf("Task \"%s\" cancelled", name)
+			result = NewWarningMsg(infoid.New(), name+" cancelled.", expiration)
+		}
+		log.Printf("Task \"%s\" finished", name)
+		ch <- TaskFinishedMsg{Task: name, Result: result}
+	}()
+	return listen(ch)
+}
+
+func listen(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		if p, ok := msg.(TaskProgressMsg); ok {
+			p.Next = listen(ch)
+			return p
+		}
+		return msg
+	}
+}
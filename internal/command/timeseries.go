@@ -0,0 +1,316 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/chart"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/dump"
+	"github.com/hirotake111/redisclient/internal/source"
+)
+
+// TypeTimeSeries is the type of RedisTimeSeries keys, as returned by TYPE.
+const TypeTimeSeries = "TSDB-TYPE"
+
+// Aggregations of the samples of a bucket, as passed to TS.RANGE. AggregationNone draws every sample.
+const AggregationNone = "none"
+
+// Aggregations lists the aggregations, e.g. for a selector.
+var Aggregations = []string{"avg", "min", "max", "sum", "count", "first", "last", AggregationNone}
+
+const (
+	// latestSamples is the number of samples displayed along with the information of a series.
+	latestSamples = 20
+	// maxSeries is the number of series a chart overlays at most.
+	maxSeries = 6
+	// chartHeaderLines is the number of lines of a chart report above the chart.
+	chartHeaderLines = 2
+)
+
+// timeSeriesValue describes a series with TS.INFO, followed by its latest samples.
+func timeSeriesValue(ctx context.Context, src source.Source, key string) (string, error) {
+	client := src.Client()
+	if client == nil {
+		return "", fmt.Errorf("%s can't read time series", src.Name())
+	}
+	reply, err := client.Do(ctx, "TS.INFO", key).Result()
+	if err != nil {
+		return "", err
+	}
+	info := replyMap(reply)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Samples:    %s (%s bytes)\n", replyString(info["totalSamples"]), replyString(info["memoryUsage"]))
+	if replyString(info["totalSamples"]) != "0" {
+		fmt.Fprintf(&sb, "From:       %s\nTo:         %s\n", sampleTime(info["firstTimestamp"]), sampleTime(info["lastTimestamp"]))
+	}
+	retention := "forever"
+	if ms, _ := info["retentionTime"].(int64); ms > 0 {
+		retention = (time.Duration(ms) * time.Millisecond).String()
+	}
+	fmt.Fprintf(&sb, "Retention:  %s\n", retention)
+	fmt.Fprintf(&sb, "Chunks:     %s of %s bytes (%s)\n", replyString(info["chunkCount"]), replyString(info["chunkSize"]), replyString(info["chunkType"]))
+	if p := replyString(info["duplicatePolicy"]); p != "" {
+		fmt.Fprintf(&sb, "Duplicates: %s\n", p)
+	}
+
+	labels := labelPairs(info["labels"])
+	fmt.Fprintf(&sb, "\nLabels (%d)\n", len(labels))
+	for _, l := range labels {
+		fmt.Fprintf(&sb, "  %s=%s\n", l[0], l[1])
+	}
+	if s := replyString(info["sourceKey"]); s != "" {
+		fmt.Fprintf(&sb, "\nCompacted from %s\n", s)
+	}
+	rules := compactionRules(info["rules"])
+	fmt.Fprintf(&sb, "\nCompaction rules (%d)\n", len(rules))
+	for _, r := range rules {
+		fmt.Fprintf(&sb, "  %s\n", r)
+	}
+
+	samples, err := client.Do(ctx, "TS.REVRANGE", key, "-", "+", "COUNT", latestSamples).Slice()
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&sb, "\nLatest samples (G draws a chart)\n")
+	for _, p := range parseSamples(samples) {
+		fmt.Fprintf(&sb, "  %s  %s\n", time.UnixMilli(p.T).Format("2006-01-02 15:04:05.000"), strconv.FormatFloat(p.V, 'g', -1, 64))
+	}
+	return sb.String(), nil
+}
+
+// ChartTimeSeries draws the samples of a series, or of the series matching a label filter
+// with TS.MRANGE, in width x height cells. The time window ends at the latest sample of
+// the series, and the whole series is drawn if window is empty. Samples are aggregated
+// in buckets, sized to the width of the chart if bucket is empty.
+func ChartTimeSeries(ctx context.Context, src source.Source, key, window, bucket, aggregation, filter string, width, height int) tea.Cmd {
+	return func() tea.Msg {
+		client, err := writableClient(src)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if key == "" {
+			return NewWarningMsg(infoid.New(), "Select a time series first.", expiration)
+		}
+		t, err := src.Type(ctx, key)
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		if t != TypeTimeSeries {
+			return NewWarningMsg(infoid.New(), fmt.Sprintf("%s is a %s, not a time series.", key, t), expiration)
+		}
+		reply, err := client.Do(ctx, "TS.INFO", key).Result()
+		if err != nil {
+			return NewErrorMsg(infoid.New(), err, expiration)
+		}
+		info := replyMap(reply)
+		first, _ := info["firstTimestamp"].(int64)
+		last, _ := info["lastTimestamp"].(int64)
+		if n, _ := info["totalSamples"].(int64); n == 0 {
+			return NewWarningMsg(infoid.New(), key+" has no samples.", expiration)
+		}
+
+		from := first
+		if window != "" {
+			span, err := parseSpan(window)
+			if err != nil {
+				return NewErrorMsg(infoid.New(), fmt.Errorf("invalid time window: %w", err), expiration)
+			}
+			from = max(first, last-span)
+		}
+		var bucketMs int64
+		if aggregation != AggregationNone {
+			if bucket != "" {
+				if bucketMs, err = parseSpan(bucket); err != nil {
+					return NewErrorMsg(infoid.New(), fmt.Errorf("invalid bucket size: %w", err), expiration)
+				}
+			} else {
+				// About one bucket per dot of the chart, which has 2 dots per cell
+				bucketMs = max(1, (last-from)/int64(max(1, width*2)))
+			}
+		}
+
+		args := []any{from, last}
+		if aggregation != AggregationNone {
+			args = append(args, "AGGREGATION", aggregation, bucketMs)
+		}
+		var series []chart.Series
+		if filter == "" {
+			samples, err := client.Do(ctx, append([]any{"TS.RANGE", key}, args...)...).Slice()
+			if err != nil {
+				return NewErrorMsg(infoid.New(), err, expiration)
+			}
+			series = []chart.Series{{Name: key, Points: parseSamples(samples)}}
+		} else {
+			filters, err := dump.SplitArgs(filter)
+			if err != nil {
+				return NewErrorMsg(infoid.New(), fmt.Errorf("invalid filter: %w", err), expiration)
+			}
+			args = append([]any{"TS.MRANGE"}, args...)
+			args = append(args, "FILTER")
+			for _, f := range filters {
+				args = append(args, f)
+			}
+			reply, err := client.Do(ctx, args...).Result()
+			if err != nil {
+				return NewErrorMsg(infoid.New(), err, expiration)
+			}
+			series = parseMultiRange(reply)
+			if len(series) == 0 {
+				return NewWarningMsg(infoid.New(), fmt.Sprintf("No time series matches %s.", filter), expiration)
+			}
+		}
+
+		var sb strings.Builder
+		how := "every sample"
+		if aggregation != AggregationNone {
+			how = fmt.Sprintf("%s per %s", aggregation, time.Duration(bucketMs)*time.Millisecond)
+		}
+		fmt.Fprintf(&sb, "%s, %s to %s", how, time.UnixMilli(from).Format("2006-01-02 15:04:05"), time.UnixMilli(last).Format("2006-01-02 15:04:05"))
+		if filter != "" {
+			fmt.Fprintf(&sb, ", %d series matching %s", len(series), filter)
+		}
+		if len(series) > maxSeries {
+			fmt.Fprintf(&sb, " (the first %d are drawn)", maxSeries)
+			series = series[:maxSeries]
+		}
+		sb.WriteString("\n\n")
+		sb.WriteString(chart.Line(series, width, height-chartHeaderLines))
+		log.Printf("Drew %d time series from %d to %d (bucket: %d ms)", len(series), from, last, bucketMs)
+		return ReportMsg{Title: "TIME SERIES " + key, Body: sb.String(), Syntax: SyntaxStyled}
+	}
+}
+
+// parseSpan parses a duration like 90s, 15m, 24h, 7d or 2w into milliseconds.
+func parseSpan(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	var d time.Duration
+	if unit != 0 {
+		n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a duration, e.g. 15m, 24h or 7d", s)
+		}
+		d = time.Duration(n * float64(unit))
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("%q is not a duration, e.g. 15m, 24h or 7d", s)
+		}
+	}
+	if d < time.Millisecond {
+		return 0, fmt.Errorf("%q is shorter than a millisecond", s)
+	}
+	return d.Milliseconds(), nil
+}
+
+// parseSamples parses samples, which are pairs of a timestamp and a value.
+// Values are strings in RESP2, and doubles in RESP3.
+func parseSamples(samples []any) []chart.Point {
+	points := make([]chart.Point, 0, len(samples))
+	for _, s := range samples {
+		pair, ok := s.([]any)
+		if !ok || len(pair) != 2 {
+			continue
+		}
+		ts, _ := pair[0].(int64)
+		v, err := strconv.ParseFloat(replyString(pair[1]), 64)
+		if err != nil {
+			continue
+		}
+		points = append(points, chart.Point{T: ts, V: v})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].T < points[j].T })
+	return points
+}
+
+// parseMultiRange parses the reply of TS.MRANGE, a list of [key, labels, samples] in RESP2,
+// and a map of keys to [labels, ..., samples] in RESP3. Series are sorted by key.
+func parseMultiRange(reply any) []chart.Series {
+	var series []chart.Series
+	add := func(key string, v any) {
+		l, ok := v.([]any)
+		if !ok || len(l) == 0 {
+			return
+		}
+		samples, _ := l[len(l)-1].([]any)
+		series = append(series, chart.Series{Name: key, Points: parseSamples(samples)})
+	}
+	switch reply := reply.(type) {
+	case []any:
+		for _, s := range reply {
+			if l, ok := s.([]any); ok && len(l) > 1 {
+				add(replyString(l[0]), l[1:])
+			}
+		}
+	case map[any]any:
+		for k, v := range reply {
+			add(replyString(k), v)
+		}
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Name < series[j].Name })
+	return series
+}
+
+// labelPairs returns the labels of TS.INFO, a list of pairs in RESP2 and a map in RESP3.
+func labelPairs(v any) [][2]string {
+	if l, ok := v.([]any); ok {
+		var labels [][2]string
+		for _, pair := range l {
+			if p, ok := pair.([]any); ok && len(p) == 2 {
+				labels = append(labels, [2]string{replyString(p[0]), replyString(p[1])})
+			}
+		}
+		return labels
+	}
+	return replyFields(v)
+}
+
+// compactionRules describes the rules of TS.INFO, a list of [key, bucket, aggregation, ...]
+// in RESP2 and a map of keys to [bucket, aggregation, ...] in RESP3.
+func compactionRules(v any) []string {
+	describe := func(key string, rule []any) string {
+		if len(rule) < 2 {
+			return key
+		}
+		bucket, _ := rule[0].(int64)
+		return fmt.Sprintf("%s per %s → %s", replyString(rule[1]), time.Duration(bucket)*time.Millisecond, key)
+	}
+	var rules []string
+	switch v := v.(type) {
+	case []any:
+		for _, r := range v {
+			if l, ok := r.([]any); ok && len(l) > 0 {
+				rules = append(rules, describe(replyString(l[0]), l[1:]))
+			}
+		}
+	case map[any]any:
+		for k, r := range v {
+			l, _ := r.([]any)
+			rules = append(rules, describe(replyString(k), l))
+		}
+		sort.Strings(rules)
+	}
+	return rules
+}
+
+func sampleTime(v any) string {
+	ms, ok := v.(int64)
+	if !ok {
+		return replyString(v)
+	}
+	return fmt.Sprintf("%s (%d)", time.UnixMilli(ms).Format("2006-01-02 15:04:05.000"), ms)
+}
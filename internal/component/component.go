@@ -4,9 +4,11 @@ import (
 	"log"
 	"strconv"
 
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/list"
 	"github.com/hirotake111/redisclient/internal/color"
+	"github.com/hirotake111/redisclient/internal/format"
 )
 
 const (
@@ -49,6 +51,8 @@ var (
 		"q/ESC/CTRL+c: quit",
 	}
 
+	progressBar = progress.New(progress.WithSolidFill(string(color.Primary)), progress.WithWidth(20), progress.WithoutPercentage())
+
 	helpTextStyle = lipgloss.NewStyle().
 			MarginRight(8).
 			Foreground(color.Primary)
@@ -80,6 +84,21 @@ func HostHeader(host string) string {
 	))
 }
 
+// TaskProgress shows the progress of a background task, e.g. "Exporting ━━━━━── 1,234/10,000".
+func TaskProgress(task string, done, total int64) string {
+	count := format.Count(done)
+	bar := ""
+	if total > 0 {
+		count += "/" + format.Count(total)
+		bar = progressBar.ViewAs(min(1, float64(done)/float64(total)))
+	}
+	return lipgloss.NewStyle().MarginLeft(4).Render(lipgloss.JoinHorizontal(lipgloss.Center,
+		headerLabelStyle.Render(task),
+		headerStyle.Render(bar),
+		headerStyle.Render(count),
+	))
+}
+
 func ValueDisplay(value string, ttl int64, width, height int) string {
 	log.Printf("Rendering value display with width: %d, height: %d, value: \"%s\"", width, height, value)
 	maxChrs := (width) * (height) // Adjust for padding and borders
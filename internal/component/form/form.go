@@ -0,0 +1,238 @@
+package form
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hirotake111/redisclient/internal/color"
+)
+
+var (
+	containerStyle = lipgloss.NewStyle().
+			Padding(0, 1).
+			BorderStyle(lipgloss.ThickBorder()).
+			BorderForeground(color.Primary)
+	titleBarStyle = lipgloss.NewStyle().MarginBottom(1).Padding(0, 1).Background(color.Primary).Foreground(color.White)
+	labelStyle    = lipgloss.NewStyle().Foreground(color.Grey).Width(16)
+	focusedStyle  = labelStyle.Foreground(color.Primary).Bold(true)
+	optionStyle   = lipgloss.NewStyle().Padding(0, 1).Foreground(color.Grey)
+	selectedStyle = optionStyle.Background(color.Primary).Foreground(color.White)
+	helpStyle     = lipgloss.NewStyle().MarginTop(1).Foreground(color.Grey)
+	inputStyle    = lipgloss.NewStyle().Foreground(color.White)
+)
+
+// Field describes a field of a form. A field with options is a selector, a text input otherwise.
+type Field struct {
+	Name        string   // Key of the field in the submitted values
+	Label       string   // Label displayed next to the field
+	Value       string   // Initial value, or selected option
+	Placeholder string   // Placeholder of text inputs
+	Options     []string // Options of selectors
+	Lines       int      // Height of a multi-line text input, in which enter starts a new line
+}
+
+type field struct {
+	Field
+	input    textinput.Model
+	area     textarea.Model
+	selected int
+}
+
+// multiline reports whether the field is a multi-line text input.
+func (f field) multiline() bool {
+	return f.Lines > 1 && len(f.Options) == 0
+}
+
+// SubmittedMsg is sent when the user submits the form with enter, or ctrl+s in multi-line inputs.
+type SubmittedMsg struct {
+	ID     string
+	Values map[string]string
+}
+
+func (s SubmittedMsg) String() string {
+	return fmt.Sprintf("form_submitted - id: %s", s.ID)
+}
+
+// CancelledMsg is sent when the user closes the form with esc.
+type CancelledMsg struct {
+	ID string
+}
+
+func (c CancelledMsg) String() string {
+	return fmt.Sprintf("form_cancelled - id: %s", c.ID)
+}
+
+// Form is a set of text inputs and selectors, displayed in place of the value viewport.
+type Form struct {
+	id     string
+	title  string
+	fields []field
+	focus  int
+}
+
+func New(id, title string, fields ...Field) Form {
+	f := Form{id: id, title: title}
+	for _, fd := range fields {
+		ff := field{Field: fd}
+		switch {
+		case len(fd.Options) > 0:
+			for i, o := range fd.Options {
+				if o == fd.Value {
+					ff.selected = i
+				}
+			}
+		case ff.multiline():
+			ff.area = textarea.New()
+			ff.area.Prompt = ""
+			ff.area.Placeholder = fd.Placeholder
+			ff.area.ShowLineNumbers = true
+			ff.area.CharLimit = 0
+			ff.area.MaxHeight = 0
+			ff.area.SetHeight(fd.Lines)
+			ff.area.SetValue(fd.Value)
+		default:
+			ff.input = textinput.New()
+			ff.input.Prompt = ""
+			ff.input.Placeholder = fd.Placeholder
+			ff.input.SetValue(fd.Value)
+		}
+		f.fields = append(f.fields, ff)
+	}
+	return f.focusField(0)
+}
+
+// ID identifies the form in the messages it sends.
+func (f Form) ID() string {
+	return f.id
+}
+
+// Values returns the current value of every field.
+func (f Form) Values() map[string]string {
+	values := make(map[string]string, len(f.fields))
+	for _, fd := range f.fields {
+		switch {
+		case len(fd.Options) > 0:
+			values[fd.Name] = fd.Options[fd.selected]
+		case fd.multiline():
+			values[fd.Name] = fd.area.Value()
+		default:
+			values[fd.Name] = fd.input.Value()
+		}
+	}
+	return values
+}
+
+func (f Form) Update(msg tea.Msg) (Form, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return f, nil
+	}
+
+	cur := &f.fields[f.focus]
+	key := keyMsg.String()
+	if cur.multiline() && (key == "enter" || key == "up" || key == "down") {
+		// Keys editing the text rather than the form
+		var cmd tea.Cmd
+		cur.area, cmd = cur.area.Update(msg)
+		return f, cmd
+	}
+
+	switch key {
+	case "esc":
+		log.Printf("Form %s cancelled", f.id)
+		return f, func() tea.Msg { return CancelledMsg{ID: f.id} }
+
+	case "enter", "ctrl+s":
+		values := f.Values()
+		log.Printf("Form %s submitted", f.id)
+		return f, func() tea.Msg { return SubmittedMsg{ID: f.id, Values: values} }
+
+	case "tab", "down":
+		return f.focusField((f.focus + 1) % len(f.fields)), nil
+
+	case "shift+tab", "up":
+		return f.focusField((f.focus - 1 + len(f.fields)) % len(f.fields)), nil
+
+	case "left", "right", " ":
+		if n := len(cur.Options); n > 0 {
+			if keyMsg.String() == "left" {
+				cur.selected = (cur.selected - 1 + n) % n
+			} else {
+				cur.selected = (cur.selected + 1) % n
+			}
+			return f, nil
+		}
+	}
+
+	if len(cur.Options) > 0 {
+		return f, nil
+	}
+	var cmd tea.Cmd
+	if cur.multiline() {
+		cur.area, cmd = cur.area.Update(msg)
+	} else {
+		cur.input, cmd = cur.input.Update(msg)
+	}
+	return f, cmd
+}
+
+func (f Form) focusField(i int) Form {
+	for j := range f.fields {
+		switch fd := &f.fields[j]; {
+		case len(fd.Options) > 0:
+		case fd.multiline():
+			fd.area.Blur()
+		default:
+			fd.input.Blur()
+		}
+	}
+	f.focus = i
+	switch fd := &f.fields[i]; {
+	case len(fd.Options) > 0:
+	case fd.multiline():
+		fd.area.Focus()
+	default:
+		fd.input.Focus()
+	}
+	return f
+}
+
+func (f Form) View(width, height int) string {
+	rows := []string{titleBarStyle.Render(f.title)}
+	help := "tab/↑/↓: move  ←/→: change option  enter: submit  esc: cancel"
+	for i, fd := range f.fields {
+		label := labelStyle.Render(fd.Label)
+		if i == f.focus {
+			label = focusedStyle.Render(fd.Label)
+		}
+
+		var value string
+		switch {
+		case len(fd.Options) > 0:
+			opts := make([]string, len(fd.Options))
+			for j, o := range fd.Options {
+				if j == fd.selected {
+					opts[j] = selectedStyle.Render(o)
+				} else {
+					opts[j] = optionStyle.Render(o)
+				}
+			}
+			value = lipgloss.JoinHorizontal(lipgloss.Left, opts...)
+		case fd.multiline():
+			fd.area.SetWidth(max(1, width-lipgloss.Width(label)-4))
+			value = fd.area.View()
+			help = "tab: move  ←/→: change option  ctrl+s: submit  esc: cancel"
+		default:
+			fd.input.Width = max(1, width-lipgloss.Width(label)-4)
+			value = inputStyle.Render(fd.input.View())
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Left, label, value))
+	}
+	rows = append(rows, helpStyle.Render(help))
+
+	return containerStyle.Width(width).Height(height).Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
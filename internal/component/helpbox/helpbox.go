@@ -14,8 +14,30 @@ var (
 		{"ENTER", "Move between value view and key list"},
 		{"/", "filter keys"},
 		{"r", "refresh keys"},
+		{"s", "cycle key sort"},
+		{"d", "cycle value decoder"},
+		{"b", "cycle text/hex/bits display"},
+		{"t", "toggle table view"},
+		{"g", "toggle geo view of sorted sets"},
+		{"/ then n/N", "search value, next/previous match"},
 		{"x", "delete key"},
 		{"X", "bulk delete filtered keys"},
+		{"e/E", "export filtered keys or DB as JSON/NDJSON"},
+		{"w/W", "export filtered keys or DB as redis-cli/RESP script"},
+		{"i", "import keys from JSON/NDJSON"},
+		{"S", "copy filtered keys or DB to another DB or server"},
+		{"H/T/O", "AOF key history, timeline, replay up to a command"},
+		{"D", "compare keys with another DB or server"},
+		{"=", "diff selected key with the compared DB or server"},
+		{"L", "Lua script workbench"},
+		{"F", "Redis 7 functions: list, code, FCALL, load, dump"},
+		{"J", "query or edit a path of a JSON document (RedisJSON)"},
+		{"Q then ]/[", "search an index (RediSearch), next/previous page"},
+		{"G", "chart a time series or series matching labels (RedisTimeSeries)"},
+		{"B", "toggle a bit of a bitmap, or read it as BITFIELD integers"},
+		{"P", "plot a geo index, or search it by radius or box (GEOSEARCH)"},
+		{"M", "check items against a Bloom/Cuckoo filter, Count-Min sketch or Top-K"},
+		{"C", "cancel the running export, import, sync or comparison"},
 		{"q or CTRL+c or ESC", " quit"},
 	}
 	helpTextkeyStyle = lipgloss.NewStyle().
@@ -9,11 +9,14 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/hirotake111/redisclient/internal/cache"
 	"github.com/hirotake111/redisclient/internal/color"
 	"github.com/hirotake111/redisclient/internal/command"
 	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/dump"
+	"github.com/hirotake111/redisclient/internal/format"
+	"github.com/hirotake111/redisclient/internal/source"
 	"github.com/hirotake111/redisclient/internal/state"
-	"github.com/redis/go-redis/v9"
 )
 
 const (
@@ -26,19 +29,30 @@ var (
 )
 
 type CustomKeyList struct {
-	model list.Model
+	model    list.Model
+	sortMode SortMode
+	cache    *cache.ValueCache
+	pageSize int64
+	hits     bool             // Whether search hits are listed instead of the keys, until the keys are refreshed
+	metric   string           // Metric the keys are sorted by, once fetched
+	metrics  map[string]int64 // Metric per key, reused by the periodic refresh
 }
 
 type item string
 
 func (i item) String() string      { return string(i) }
-func (i item) Title() string       { return i.String() }
+func (i item) Title() string       { return format.Printable(i.String()) } // Binary keys are escaped for display
 func (i item) Description() string { return i.Title() }
-func (i item) FilterValue() string { return i.Title() }
+func (i item) FilterValue() string { return i.String() }
 
-func New(keys []string, width, height int) CustomKeyList {
+func New(keys []string, width, height int, c *cache.ValueCache, pageSize int64) CustomKeyList {
+	m := newItems(keys, width, height)
+	m.Title = listTitle(SortLexical, "")
 	return CustomKeyList{
-		model: newItems(keys, width, height),
+		model:    m,
+		sortMode: SortLexical,
+		cache:    c,
+		pageSize: pageSize,
 	}
 }
 
@@ -55,10 +69,25 @@ func newItems(keys []string, widt, height int) list.Model {
 	l.Title = "KEYS"
 	l.Styles.Title = l.Styles.Title.Background(color.Primary)
 	l.SetShowHelp(false)
+	// G charts time series, the last key is still reached with end
+	l.KeyMap.GoToEnd.SetKeys("end")
 	return l
 }
 
-func (l CustomKeyList) Update(ctx context.Context, client *redis.Client, msg tea.Msg, st state.AppState) (CustomKeyList, tea.Cmd) {
+// listTitle returns the title of the list sorted by mode, with the metric fetched for
+// it, if any, and its direction.
+func listTitle(mode SortMode, metric string) string {
+	if metric == "" {
+		return fmt.Sprintf("KEYS ↑%s", mode)
+	}
+	arrow := "↑"
+	if descending(metric) {
+		arrow = "↓"
+	}
+	return fmt.Sprintf("KEYS %s%s (%s)", arrow, mode, metric)
+}
+
+func (l CustomKeyList) Update(ctx context.Context, src source.Source, msg tea.Msg, st state.AppState) (CustomKeyList, tea.Cmd) {
 	if !st.ListActive() {
 		return l, nil
 	}
@@ -71,33 +100,35 @@ func (l CustomKeyList) Update(ctx context.Context, client *redis.Client, msg tea
 
 	if msg, ok := msg.(command.KeyDeletedMsg); ok {
 		l.removeKeyFromList()
-		t := fmt.Sprintf("Key '%s' deleted successfully.", msg.Key)
+		t := fmt.Sprintf("Key '%s' deleted successfully.", format.Printable(msg.Key))
 		cmds = append(cmds, command.NewInfoInfoCmd(infoid.New(), t, 5*time.Second))
 	}
 
 	if msg, ok := msg.(command.KeysUpdatedMsg); ok {
-		prev := l.model.SelectedItem()
-		items := newItems(msg.Keys, l.model.Width(), l.model.Height())
-		l.model = items
-		// Restore previous cursor position
-		if prev != nil {
-			pi, ok := prev.(item)
-			if !ok {
-				cmds = append(cmds, command.NewErrorInfoCmd(infoid.New(), fmt.Errorf("failed to assert previous item type"), 5*time.Second))
-			}
-			for i, a := range l.model.Items() {
-				if a.(item).Title() == pi.Title() {
-					log.Printf("Restoring cursor position to index %d for item: %+v", i, a)
-					l.model.Select(i)
-					break
-				}
-			}
-
-			if selected := l.model.SelectedItem(); selected != nil {
-				cmds = append(cmds, command.GetValue(ctx, client, selected.FilterValue()))
-			}
+		metric := l.sortMode.Metric()
+		switch {
+		case metric != "" && msg.Polled && l.metrics != nil:
+			// Fetching the metrics of every key again is costly on large keyspaces,
+			// they are only fetched on explicit refreshes and sort mode changes
+			sortKeysByMetric(msg.Keys, l.metric, l.metrics)
+		case metric != "":
+			// Keep the current list until the keys are sorted to avoid flickering
+			return l, command.GetKeyMetrics(ctx, src, msg.Keys, metric)
+		default:
+			sortKeys(msg.Keys, l.sortMode)
 		}
+		l, cmds = l.setKeys(ctx, src, msg.Keys, cmds)
+		return l, tea.Batch(cmds...)
+	}
 
+	if msg, ok := msg.(command.KeyMetricsUpdatedMsg); ok {
+		if msg.Requested != l.sortMode.Metric() {
+			log.Print("Sort mode changed while fetching metrics - ignoring")
+			return l, nil
+		}
+		l.metric, l.metrics = msg.Metric, msg.Metrics
+		sortKeysByMetric(msg.Keys, msg.Metric, msg.Metrics)
+		l, cmds = l.setKeys(ctx, src, msg.Keys, cmds)
 		return l, tea.Batch(cmds...)
 	}
 
@@ -110,22 +141,39 @@ func (l CustomKeyList) Update(ctx context.Context, client *redis.Client, msg tea
 			cmds = append(cmds, state.ActivateViewportCmd)
 
 		case key == "x":
-			l, cmds = l.DeleteKey(ctx, client, cmds)
+			l, cmds = l.DeleteKey(ctx, src, cmds)
 
 		case key == "X":
-			l, cmds = l.BulkDelete(ctx, client, cmds)
+			l, cmds = l.BulkDelete(ctx, src, cmds)
 
 		case key == "r":
 			// Avoid refreshing while filtering (otherwise it gets refreshed when pressing r key)
 			if l.model.FilterState() != list.Filtering {
 				log.Print("key 'r' pressed, refreshing key list")
-				cmds = append(cmds, command.GetKeys(ctx, client, ""))
+				l.cache.Purge()
+				cmds = append(cmds, command.GetKeys(ctx, src, ""))
+			}
+
+		case key == "s":
+			if l.model.FilterState() == list.Unfiltered {
+				l, cmds = l.CycleSortMode(ctx, src, cmds)
+				return l, tea.Batch(cmds...)
+			}
+
+		case key == "e" || key == "E" || key == "w" || key == "W":
+			if l.model.FilterState() != list.Filtering {
+				l, cmds = l.Export(exportFormats[key], cmds)
+			}
+
+		case key == "S":
+			if l.model.FilterState() != list.Filtering {
+				l, cmds = l.Sync(cmds)
 			}
 
 		case key == "y":
 			if l.model.FilterState() != list.Filtering {
 				log.Print("key 'y' pressed, copying current key to clipboard")
-				cmds = append(cmds, command.CopyValueToClipboard(ctx, l.model.SelectedItem().FilterValue()))
+				cmds = append(cmds, command.RequestCopy(l.model.SelectedItem().FilterValue()))
 			}
 		}
 	}
@@ -137,7 +185,7 @@ func (l CustomKeyList) Update(ctx context.Context, client *redis.Client, msg tea
 	}
 
 	if l.ShouldUpdateValue(prv) {
-		cmds = append(cmds, command.GetValue(ctx, client, l.model.SelectedItem().FilterValue()))
+		cmds = append(cmds, command.GetValue(ctx, src, l.cache, l.model.SelectedItem().FilterValue(), l.pageSize))
 	} else {
 		log.Print("No change in selected key")
 	}
@@ -156,7 +204,102 @@ func (l *CustomKeyList) View(width, height int, st state.AppState) string {
 	return style.Width(width).Height(height).Render(l.model.View())
 }
 
-func (l CustomKeyList) DeleteKey(ctx context.Context, client *redis.Client, cmds []tea.Cmd) (CustomKeyList, []tea.Cmd) {
+// setKeys replaces the items in the list with keys, restoring the cursor position.
+func (l CustomKeyList) setKeys(ctx context.Context, src source.Source, keys []string, cmds []tea.Cmd) (CustomKeyList, []tea.Cmd) {
+	prev := l.model.SelectedItem()
+	l.model = newItems(keys, l.model.Width(), l.model.Height())
+	l.model.Title = listTitle(l.sortMode, l.metric)
+	l.hits = false
+	// Restore previous cursor position
+	if prev != nil {
+		pi, ok := prev.(item)
+		if !ok {
+			cmds = append(cmds, command.NewErrorInfoCmd(infoid.New(), fmt.Errorf("failed to assert previous item type"), 5*time.Second))
+		}
+		for i, a := range l.model.Items() {
+			if a.(item) == pi {
+				log.Printf("Restoring cursor position to index %d for item: %+v", i, a)
+				l.model.Select(i)
+				break
+			}
+		}
+
+		if selected := l.model.SelectedItem(); selected != nil {
+			cmds = append(cmds, command.GetValue(ctx, src, l.cache, selected.FilterValue(), l.pageSize))
+		}
+	}
+	return l, cmds
+}
+
+// ShowHits lists the documents found by a search in their order, instead of the keys,
+// until the keys are refreshed. The value of the first hit is not fetched, so that
+// the results of the search stay in the viewport.
+func (l CustomKeyList) ShowHits(keys []string, title string) CustomKeyList {
+	l.model = newItems(keys, l.model.Width(), l.model.Height())
+	l.model.Title = title
+	l.hits = true
+	return l
+}
+
+// ShowingHits reports whether search hits are listed instead of the keys.
+func (l CustomKeyList) ShowingHits() bool {
+	return l.hits
+}
+
+// CycleSortMode switches to the next sort mode and re-sorts the current keys.
+func (l CustomKeyList) CycleSortMode(ctx context.Context, src source.Source, cmds []tea.Cmd) (CustomKeyList, []tea.Cmd) {
+	l.sortMode = l.sortMode.Next()
+	l.metric, l.metrics = "", nil
+	log.Printf("key 's' pressed, sorting keys by %s", l.sortMode)
+	keys := make([]string, 0, len(l.model.Items()))
+	for _, it := range l.model.Items() {
+		keys = append(keys, it.FilterValue())
+	}
+	if metric := l.sortMode.Metric(); metric != "" {
+		cmds = append(cmds, command.GetKeyMetrics(ctx, src, keys, metric))
+		return l, cmds
+	}
+	sortKeys(keys, l.sortMode)
+	return l.setKeys(ctx, src, keys, cmds)
+}
+
+// exportFormats maps export key bindings to file formats.
+var exportFormats = map[string]string{
+	"e": dump.FormatJSON,
+	"E": dump.FormatNDJSON,
+	"w": dump.FormatInline,
+	"W": dump.FormatRESP,
+}
+
+// Export exports the filtered keys, or the whole database if no filter is applied.
+func (l CustomKeyList) Export(fileFormat string, cmds []tea.Cmd) (CustomKeyList, []tea.Cmd) {
+	keys := l.FilteredKeys()
+	log.Printf("Exporting %d keys (nil for the whole database) as %s", len(keys), fileFormat)
+	cmds = append(cmds, command.RequestExport(keys, fileFormat))
+	return l, cmds
+}
+
+// Sync copies the filtered keys, or the whole database if no filter is applied, to another database.
+func (l CustomKeyList) Sync(cmds []tea.Cmd) (CustomKeyList, []tea.Cmd) {
+	keys := l.FilteredKeys()
+	log.Printf("Syncing %d keys (nil for the whole database)", len(keys))
+	cmds = append(cmds, command.RequestSync(keys))
+	return l, cmds
+}
+
+// FilteredKeys returns the keys matching the applied filter, or nil if no filter is applied.
+func (l CustomKeyList) FilteredKeys() []string {
+	if l.model.FilterState() != list.FilterApplied {
+		return nil
+	}
+	keys := make([]string, 0, len(l.model.VisibleItems()))
+	for _, it := range l.model.VisibleItems() {
+		keys = append(keys, it.FilterValue())
+	}
+	return keys
+}
+
+func (l CustomKeyList) DeleteKey(ctx context.Context, src source.Source, cmds []tea.Cmd) (CustomKeyList, []tea.Cmd) {
 	log.Print("key 'x' pressed, deleting current key")
 	si := l.model.SelectedItem()
 	if si == nil {
@@ -171,11 +314,11 @@ func (l CustomKeyList) DeleteKey(ctx context.Context, client *redis.Client, cmds
 	}
 
 	log.Printf("Deleting key: %s", k)
-	cmds = append(cmds, command.DeleteKey(ctx, client, k))
+	cmds = append(cmds, command.DeleteKey(ctx, src, l.cache, k))
 	return l, cmds
 }
 
-func (l CustomKeyList) BulkDelete(ctx context.Context, client *redis.Client, cmds []tea.Cmd) (CustomKeyList, []tea.Cmd) {
+func (l CustomKeyList) BulkDelete(ctx context.Context, src source.Source, cmds []tea.Cmd) (CustomKeyList, []tea.Cmd) {
 	if len(l.model.VisibleItems()) == 0 {
 		log.Print("No visible items to delete in bulk - skipping deletion")
 		return l, cmds
@@ -186,7 +329,7 @@ func (l CustomKeyList) BulkDelete(ctx context.Context, client *redis.Client, cmd
 	for _, it := range l.model.VisibleItems() {
 		keys = append(keys, it.FilterValue())
 	}
-	cmds = append(cmds, command.BulkDelete(ctx, client, keys))
+	cmds = append(cmds, command.BulkDelete(ctx, src, l.cache, keys))
 	return l, cmds
 }
 func (l *CustomKeyList) removeKeyFromList() {
@@ -226,3 +369,12 @@ func (l CustomKeyList) IsBeingUnfiltered() bool {
 func (l CustomKeyList) IsFitering() bool {
 	return l.model.FilterState() == list.Filtering
 }
+
+// SelectedKey returns the key under the cursor, or an empty string if the list is empty.
+func (l CustomKeyList) SelectedKey() string {
+	si := l.model.SelectedItem()
+	if si == nil {
+		return ""
+	}
+	return si.FilterValue()
+}
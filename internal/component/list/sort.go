@@ -0,0 +1,132 @@
+package list
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/hirotake111/redisclient/internal/source"
+)
+
+// SortMode represents the order in which keys are displayed.
+type SortMode int
+
+const (
+	SortLexical SortMode = iota
+	SortNatural
+	SortTTL
+	SortMemory
+	SortAccess
+	numSortModes
+)
+
+func (s SortMode) String() string {
+	switch s {
+	case SortNatural:
+		return "natural"
+	case SortTTL:
+		return "ttl"
+	case SortMemory:
+		return "size"
+	case SortAccess:
+		return "access"
+	default:
+		return "name"
+	}
+}
+
+// Next returns the sort mode that follows s, wrapping around.
+func (s SortMode) Next() SortMode {
+	return (s + 1) % numSortModes
+}
+
+// Metric returns the server-side metric the sort mode relies on,
+// or an empty string if keys can be sorted locally.
+func (s SortMode) Metric() string {
+	switch s {
+	case SortTTL:
+		return source.MetricTTL
+	case SortMemory:
+		return source.MetricMemory
+	case SortAccess:
+		return source.MetricAccess
+	default:
+		return ""
+	}
+}
+
+// sortKeys sorts keys in place by name.
+func sortKeys(keys []string, mode SortMode) {
+	if mode == SortNatural {
+		slices.SortStableFunc(keys, naturalCompare)
+		return
+	}
+	slices.Sort(keys)
+}
+
+// sortKeysByMetric sorts keys in place by the given metric.
+// Keys without a metric (-1, or missing, e.g. keys created since the metrics were
+// fetched) always go last, ties are broken by name.
+func sortKeysByMetric(keys []string, metric string, metrics map[string]int64) {
+	desc := descending(metric)
+	slices.SortStableFunc(keys, func(a, b string) int {
+		ma, mb := metricOf(metrics, a), metricOf(metrics, b)
+		switch {
+		case ma == mb:
+			return naturalCompare(a, b)
+		case ma < 0:
+			return 1
+		case mb < 0:
+			return -1
+		case desc:
+			return cmp.Compare(mb, ma)
+		default:
+			return cmp.Compare(ma, mb)
+		}
+	})
+}
+
+func metricOf(metrics map[string]int64, key string) int64 {
+	if m, ok := metrics[key]; ok {
+		return m
+	}
+	return -1
+}
+
+// descending reports whether keys are sorted by the metric in descending order,
+// the largest and most frequently used keys first.
+func descending(metric string) bool {
+	return metric == source.MetricMemory || metric == source.MetricFreq
+}
+
+// naturalCompare compares two strings treating runs of digits as numbers,
+// so that "key:2" comes before "key:10".
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		da, db := digitPrefix(a), digitPrefix(b)
+		if da != "" && db != "" {
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if len(na) != len(nb) {
+				return len(na) - len(nb)
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+func digitPrefix(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
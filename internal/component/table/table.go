@@ -0,0 +1,279 @@
+package table
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hirotake111/redisclient/internal/color"
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/format"
+	"github.com/hirotake111/redisclient/internal/values"
+)
+
+const (
+	noSort        = -1
+	rankWidth     = 8
+	scoreWidth    = 16
+	statusHeight  = 1
+	cellPaddingX2 = 2 // Cells are padded by 1 on each side
+)
+
+var (
+	headerStyle   = lipgloss.NewStyle().Bold(true).Padding(0, 1).Foreground(color.Primary)
+	cellStyle     = lipgloss.NewStyle().Padding(0, 1)
+	selectedStyle = lipgloss.NewStyle().Background(color.Primary).Foreground(color.White)
+	statusStyle   = lipgloss.NewStyle().Foreground(color.Grey)
+)
+
+// Supports reports whether values of the given type can be displayed as a table.
+func Supports(t string) bool {
+	return t == "hash" || t == "zset"
+}
+
+// Table displays the elements of a hash (field | value) or a sorted set (rank | member | score).
+type Table struct {
+	model      table.Model
+	valueType  string
+	columns    []string
+	rows       [][]string // Raw cells in server order
+	visible    []int      // Indexes of the displayed rows, after searching and sorting
+	column     int        // Selected column
+	sortColumn int        // Column rows are sorted by, or noSort for server order
+	sortDesc   bool
+	search     textinput.Model
+	searching  bool // Whether the search input is focused
+}
+
+func New() Table {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	return Table{
+		model: table.New(
+			table.WithFocused(true),
+			table.WithStyles(table.Styles{Header: headerStyle, Cell: cellStyle, Selected: selectedStyle}),
+		),
+		sortColumn: noSort,
+		search:     ti,
+	}
+}
+
+// SetCollection replaces the rows with the elements of c, keeping the cursor, sorting and search.
+func (t Table) SetCollection(c values.Collection) Table {
+	if c.Type != t.valueType {
+		t.column = 0
+		t.sortColumn = noSort
+	}
+	t.valueType = c.Type
+	t.rows = make([][]string, 0, len(c.Elements))
+	switch c.Type {
+	case "zset":
+		t.columns = []string{"rank", "member", "score"}
+		for i, e := range c.Elements {
+			t.rows = append(t.rows, []string{strconv.Itoa(i), e.Field, strconv.FormatFloat(e.Score, 'g', -1, 64)})
+		}
+	default:
+		t.columns = []string{"field", "value"}
+		for _, e := range c.Elements {
+			t.rows = append(t.rows, []string{e.Field, e.Value})
+		}
+	}
+	return t.refresh()
+}
+
+// Reset clears the search, sorting and cursor, e.g. when another key is displayed.
+func (t Table) Reset() Table {
+	t.search.SetValue("")
+	t.search.Blur()
+	t.searching = false
+	t.sortColumn = noSort
+	t.sortDesc = false
+	t.column = 0
+	t.model.GotoTop()
+	return t
+}
+
+// Searching reports whether the search input is focused, in which case it takes every key.
+func (t Table) Searching() bool {
+	return t.searching
+}
+
+// AtBottom reports whether the cursor is on the last row.
+func (t Table) AtBottom() bool {
+	return t.model.Cursor() >= len(t.visible)-1
+}
+
+func (t Table) Update(msg tea.Msg) (Table, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return t, nil
+	}
+
+	var cmd tea.Cmd
+	if t.searching {
+		switch keyMsg.String() {
+		case "enter":
+			t.searching = false
+			t.search.Blur()
+		case "esc":
+			t.searching = false
+			t.search.Blur()
+			t.search.SetValue("")
+			t = t.refresh()
+		default:
+			t.search, cmd = t.search.Update(keyMsg)
+			t = t.refresh()
+		}
+		return t, cmd
+	}
+
+	switch keyMsg.String() {
+	case "/":
+		t.searching = true
+		return t, t.search.Focus()
+
+	case "h", "left":
+		t.column = max(0, t.column-1)
+
+	case "l", "right":
+		t.column = min(len(t.columns)-1, t.column+1)
+
+	case "o":
+		t = t.cycleSort()
+
+	case "y":
+		if cell, ok := t.selectedCell(); ok {
+			return t, command.RequestCopy(cell)
+		}
+
+	default:
+		t.model, cmd = t.model.Update(keyMsg)
+	}
+	return t, cmd
+}
+
+func (t Table) View(width, height int) string {
+	t.model.SetColumns(t.columnsFor(width))
+	t.model.SetHeight(max(1, height-statusHeight))
+	t.model.SetWidth(width)
+	return lipgloss.JoinVertical(lipgloss.Left, t.status(), t.model.View())
+}
+
+// cycleSort sorts by the selected column ascending, then descending, then goes back to server order.
+func (t Table) cycleSort() Table {
+	switch {
+	case t.sortColumn != t.column:
+		t.sortColumn = t.column
+		t.sortDesc = false
+	case !t.sortDesc:
+		t.sortDesc = true
+	default:
+		t.sortColumn = noSort
+		t.sortDesc = false
+	}
+	log.Printf("Sorting table by column %d (desc: %v)", t.sortColumn, t.sortDesc)
+	return t.refresh()
+}
+
+// refresh recomputes the visible rows from the search query and sort order.
+func (t Table) refresh() Table {
+	query := strings.ToLower(t.search.Value())
+	t.visible = make([]int, 0, len(t.rows))
+	for i, r := range t.rows {
+		if query == "" || slices.ContainsFunc(r, func(c string) bool { return strings.Contains(strings.ToLower(c), query) }) {
+			t.visible = append(t.visible, i)
+		}
+	}
+
+	if t.sortColumn != noSort {
+		col := t.sortColumn
+		slices.SortStableFunc(t.visible, func(a, b int) int {
+			c := compareCells(t.rows[a][col], t.rows[b][col])
+			if t.sortDesc {
+				return -c
+			}
+			return c
+		})
+	}
+
+	rows := make([]table.Row, 0, len(t.visible))
+	for _, i := range t.visible {
+		row := make(table.Row, len(t.rows[i]))
+		for j, c := range t.rows[i] {
+			row[j] = format.Printable(c)
+		}
+		rows = append(rows, row)
+	}
+	t.model.SetRows(rows)
+	if t.model.Cursor() >= len(rows) {
+		t.model.SetCursor(max(0, len(rows)-1))
+	}
+	return t
+}
+
+func (t Table) selectedCell() (string, bool) {
+	cur := t.model.Cursor()
+	if cur < 0 || cur >= len(t.visible) {
+		return "", false
+	}
+	return t.rows[t.visible[cur]][t.column], true
+}
+
+// columnsFor lays out the columns to fill width, highlighting the selected and sorted columns.
+func (t Table) columnsFor(width int) []table.Column {
+	widths := make([]int, len(t.columns))
+	switch t.valueType {
+	case "zset":
+		widths[0] = rankWidth
+		widths[2] = scoreWidth
+		widths[1] = max(1, width-rankWidth-scoreWidth-len(t.columns)*cellPaddingX2)
+	default:
+		avail := max(2, width-len(t.columns)*cellPaddingX2)
+		widths[0] = avail * 2 / 5
+		widths[1] = avail - widths[0]
+	}
+
+	cols := make([]table.Column, len(t.columns))
+	for i, name := range t.columns {
+		title := name
+		if i == t.sortColumn {
+			if t.sortDesc {
+				title += " ↓"
+			} else {
+				title += " ↑"
+			}
+		}
+		if i == t.column {
+			title = "[" + title + "]"
+		}
+		cols[i] = table.Column{Title: strings.ToUpper(title), Width: widths[i]}
+	}
+	return cols
+}
+
+func (t Table) status() string {
+	if t.searching || t.search.Value() != "" {
+		return lipgloss.JoinHorizontal(lipgloss.Left,
+			t.search.View(),
+			statusStyle.Render(fmt.Sprintf(" (%d of %d rows)", len(t.visible), len(t.rows))),
+		)
+	}
+	return statusStyle.Render("h/l: column  o: sort  /: search  y: copy cell  t: raw view")
+}
+
+// compareCells compares numerically when both cells are numbers, lexically otherwise.
+func compareCells(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return cmp.Compare(fa, fb)
+	}
+	return strings.Compare(a, b)
+}
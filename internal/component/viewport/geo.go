@@ -0,0 +1,48 @@
+package viewport
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hirotake111/redisclient/internal/format"
+	"github.com/hirotake111/redisclient/internal/values"
+)
+
+// showGeo reports whether the sorted set is displayed as a geo index, once toggled with g.
+// Any 52-bit score is a valid geohash, so sorted sets whose scores only look like geohashes,
+// such as timestamps in microseconds, aren't displayed as geo indexes by default.
+func (v Viewport) showGeo() bool {
+	return v.collection != nil && v.collection.Type == "zset" && v.geo[v.key]
+}
+
+// suggestGeo reports whether the sorted set looks like a geo index, but isn't displayed as one.
+func (v Viewport) suggestGeo() bool {
+	return v.collection != nil && !v.showGeo() && v.collection.LooksGeo()
+}
+
+// geoListing lists the members of a geo index with the longitude and latitude decoded
+// from their scores, which are those GEOPOS returns.
+func geoListing(c values.Collection) string {
+	var sb strings.Builder
+	sb.WriteString("Geo index (g displays the sorted set, P plots and searches it)\n\n")
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "member\tlongitude\tlatitude\n")
+	for _, e := range c.Elements {
+		lon, lat := values.DecodeGeohash(e.Score)
+		fmt.Fprintf(tw, "%s\t%.6f\t%.6f\n", format.Printable(e.Field), lon, lat)
+	}
+	_ = tw.Flush()
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func geoIndicator(geo, suggest bool) string {
+	switch {
+	case geo:
+		return cacheIndicatorStyle.Render(" [geo]")
+	case suggest:
+		return cacheIndicatorStyle.Render(" [g: geo?]")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,55 @@
+package viewport
+
+import (
+	"fmt"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/format"
+)
+
+// requestNextPage asks for the next page of the collection, unless it is fully loaded,
+// a page is already on its way, or the element cap has been reached.
+func (v Viewport) requestNextPage() (Viewport, tea.Cmd) {
+	c := v.collection
+	if c == nil || c.Done || v.loading || len(c.Elements) >= v.maxElements {
+		return v, nil
+	}
+
+	log.Printf("Requesting next page of \"%s\" at cursor %d", v.key, c.Cursor)
+	v.loading = true
+	msg := command.ValuePageRequestedMsg{Key: v.key, Type: c.Type, Cursor: c.Cursor}
+	return v, func() tea.Msg { return msg }
+}
+
+func (v Viewport) appendPage(msg command.ValuePageLoadedMsg) (Viewport, tea.Cmd) {
+	if v.collection == nil || msg.Key != v.key {
+		log.Printf("Ignoring page of \"%s\" as \"%s\" is displayed", msg.Key, v.key)
+		return v, nil
+	}
+
+	c := v.collection.Append(msg.Page)
+	v.collection = &c
+	v.value = c.JSON()
+	v.loading = false
+	return v.render()
+}
+
+func (v Viewport) pageIndicator() string {
+	c := v.collection
+	if c == nil || (c.Done && int64(len(c.Elements)) >= c.Total) {
+		return ""
+	}
+
+	var txt string
+	if len(c.Elements) == 0 {
+		txt = fmt.Sprintf(" (showing 0 of %s)", format.Count(c.Total))
+	} else {
+		txt = fmt.Sprintf(" (showing 1–%s of %s)", format.Count(int64(len(c.Elements))), format.Count(c.Total))
+	}
+	if len(c.Elements) >= v.maxElements && !c.Done {
+		txt += " capped"
+	}
+	return cacheIndicatorStyle.Render(txt)
+}
@@ -0,0 +1,214 @@
+package viewport
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hirotake111/redisclient/internal/color"
+)
+
+var (
+	matchStyle        = lipgloss.NewStyle().Background(color.Warning).Foreground(color.Black)
+	currentMatchStyle = lipgloss.NewStyle().Background(color.Primary).Foreground(color.White)
+)
+
+// match is the position of a match in the plain content.
+type match struct {
+	line       int
+	start, end int // Byte offsets in the line
+}
+
+// search holds the state of the search within the value.
+type search struct {
+	input         textinput.Model
+	typing        bool // Whether the search input is focused
+	caseSensitive bool
+	regex         bool
+	matches       []match
+	current       int   // Index of the current match
+	err           error // Error compiling the query as a regular expression
+}
+
+func newSearch() search {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.Placeholder = "search (ctrl+t: case, ctrl+r: regex)"
+	return search{input: ti}
+}
+
+func (s search) query() string {
+	return s.input.Value()
+}
+
+// active reports whether the search input should be displayed.
+func (s search) active() bool {
+	return s.typing || s.query() != ""
+}
+
+func (s search) pattern() (*regexp.Regexp, error) {
+	q := s.query()
+	if !s.regex {
+		q = regexp.QuoteMeta(q)
+	}
+	if !s.caseSensitive {
+		q = "(?i)" + q
+	}
+	return regexp.Compile(q)
+}
+
+func (v Viewport) startSearch() (Viewport, tea.Cmd) {
+	log.Print("key '/' pressed, searching value")
+	v.search.typing = true
+	return v, v.search.input.Focus()
+}
+
+func (v Viewport) updateSearch(msg tea.Msg) (Viewport, tea.Cmd) {
+	var cmd tea.Cmd
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			v.search.typing = false
+			v.search.input.Blur()
+			return v, nil
+
+		case "esc":
+			v.search.typing = false
+			v.search.input.Blur()
+			v.search.input.SetValue("")
+			return v.setContent(), nil
+
+		case "ctrl+t":
+			v.search.caseSensitive = !v.search.caseSensitive
+			return v.setContent(), nil
+
+		case "ctrl+r":
+			v.search.regex = !v.search.regex
+			return v.setContent(), nil
+
+		case "n", "N":
+			if !v.search.typing {
+				return v.jumpToMatch(msg.String() == "n"), nil
+			}
+		}
+	}
+
+	v.search.input, cmd = v.search.input.Update(msg)
+	v = v.setContent()
+	if len(v.search.matches) > 0 {
+		// Incremental search: follow the first match as the query changes
+		v.search.current = 0
+		v = v.scrollToMatch()
+	}
+	return v, cmd
+}
+
+func (v Viewport) jumpToMatch(forward bool) Viewport {
+	n := len(v.search.matches)
+	if n == 0 {
+		return v
+	}
+	if forward {
+		v.search.current = (v.search.current + 1) % n
+	} else {
+		v.search.current = (v.search.current - 1 + n) % n
+	}
+	return v.scrollToMatch().setContent()
+}
+
+// scrollToMatch scrolls so that the current match is in the middle of the viewport.
+func (v Viewport) scrollToMatch() Viewport {
+	m := v.search.matches[v.search.current]
+	v.model.SetYOffset(max(0, m.line-v.model.Height/2))
+	return v
+}
+
+// setContent sets the viewport content, highlighting matches if a search is active.
+func (v Viewport) setContent() Viewport {
+	q := v.search.query()
+	v.search.matches = nil
+	v.search.err = nil
+	if q == "" {
+		v.model.SetContent(v.content)
+		return v
+	}
+
+	re, err := v.search.pattern()
+	if err != nil {
+		v.search.err = err
+		v.model.SetContent(v.content)
+		return v
+	}
+
+	lines := strings.Split(v.plain, "\n")
+	for i, line := range lines {
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			if loc[0] == loc[1] {
+				continue // Skip empty matches, e.g. for "a*"
+			}
+			v.search.matches = append(v.search.matches, match{line: i, start: loc[0], end: loc[1]})
+		}
+	}
+	if v.search.current >= len(v.search.matches) {
+		v.search.current = 0
+	}
+
+	v.model.SetContent(highlight(lines, v.search.matches, v.search.current))
+	return v
+}
+
+// highlight renders lines with every match highlighted, and the current one emphasized.
+func highlight(lines []string, matches []match, current int) string {
+	var sb strings.Builder
+	mi := 0
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		pos := 0
+		for ; mi < len(matches) && matches[mi].line == i; mi++ {
+			m := matches[mi]
+			sb.WriteString(line[pos:m.start])
+			style := matchStyle
+			if mi == current {
+				style = currentMatchStyle
+			}
+			sb.WriteString(style.Render(line[m.start:m.end]))
+			pos = m.end
+		}
+		sb.WriteString(line[pos:])
+	}
+	return sb.String()
+}
+
+func (v Viewport) searchIndicator() string {
+	s := v.search
+	if s.query() == "" || v.showTable() {
+		return ""
+	}
+
+	var flags []string
+	if s.caseSensitive {
+		flags = append(flags, "case")
+	}
+	if s.regex {
+		flags = append(flags, "regex")
+	}
+	var txt string
+	switch {
+	case s.err != nil:
+		txt = " [invalid regex]"
+	case len(s.matches) == 0:
+		txt = " [no matches]"
+	default:
+		txt = fmt.Sprintf(" [%d/%d]", s.current+1, len(s.matches))
+	}
+	if len(flags) > 0 {
+		txt += " (" + strings.Join(flags, ",") + ")"
+	}
+	return cacheIndicatorStyle.Render(txt)
+}
@@ -1,18 +1,23 @@
 package viewport
 
 import (
-	"context"
-	"fmt"
 	"log"
 	"strconv"
-	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/hirotake111/redisclient/internal/color"
 	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/table"
+	"github.com/hirotake111/redisclient/internal/decoder"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/format"
 	"github.com/hirotake111/redisclient/internal/state"
+	"github.com/hirotake111/redisclient/internal/values"
 )
 
 var (
@@ -25,40 +30,153 @@ var (
 
 	// Styles for various UI components
 	titleBarStyle = lipgloss.NewStyle().MarginBottom(1).Padding(0, 1).Background(color.Primary).Foreground(color.White)
+
+	cacheIndicatorStyle = lipgloss.NewStyle().Foreground(color.Grey)
+)
+
+// DisplayMode represents how string values are displayed.
+type DisplayMode int
+
+const (
+	DisplayAuto DisplayMode = iota // Hex dump if the value is not valid UTF-8, text otherwise
+	DisplayText
+	DisplayHex
+	DisplayBits // Grid of bits, for bitmaps
+	numDisplayModes
 )
 
+func (d DisplayMode) String() string {
+	switch d {
+	case DisplayText:
+		return "text"
+	case DisplayHex:
+		return "hex"
+	case DisplayBits:
+		return "bits"
+	default:
+		return "auto"
+	}
+}
+
+const defaultTitle = "VALUE"
+
 type Viewport struct {
-	model viewport.Model
-	ttl   int64
-	value string
+	model       viewport.Model
+	title       string
+	report      bool   // Whether a report is displayed rather than a value
+	syntax      string // Language of the report to highlight, if any
+	ttl         int64
+	key         string
+	value       string
+	valueType   string
+	collection  *values.Collection // Loaded elements of collection types, nil for strings
+	loading     bool               // Whether the next page of the collection has been requested
+	maxElements int                // Maximum number of collection elements to load
+	fetchedAt   time.Time
+	cached      bool
+	display     DisplayMode
+	hex         bool // Whether the value is currently displayed as a hex dump
+	bits        bool // Whether the value is currently displayed as a grid of bits
+	decoders    *decoder.Pipeline
+	decodeMode  string // Decoder picked by the user, or decoder.ModeAuto
+	decoded     string // Decoders applied to the current value
+	table       table.Table
+	tableView   bool   // Whether hashes and sorted sets are displayed as a table
+	content     string // Rendered value, possibly colored
+	plain       string // Rendered value without colors, searched and highlighted
+	search      search
+	geo         map[string]bool // Whether sorted sets are displayed as geo indexes, by key, once toggled
 }
 
-func New(width, height int) Viewport {
+func New(width, height int, decoders *decoder.Pipeline, maxElements int) Viewport {
 	return Viewport{
-		model: viewport.New(width, height),
-		ttl:   0,
-		value: "",
+		model:       viewport.New(width, height),
+		title:       defaultTitle,
+		ttl:         0,
+		value:       "",
+		maxElements: maxElements,
+		decoders:    decoders,
+		decodeMode:  decoder.ModeAuto,
+		display:     DisplayAuto,
+		table:       table.New(),
+		tableView:   true,
+		geo:         make(map[string]bool),
+		search:      newSearch(),
 	}
 }
 
 func (v Viewport) View(width, height int, st state.AppState) string {
 	v.model.Width = width - 2
 	v.model.Height = height - 2
-	title := ValueTitle(v.ttl)
+	ttl, cached := v.ttl, v.cached
+	if v.report {
+		ttl, cached = 0, false
+	}
+	title := lipgloss.JoinHorizontal(lipgloss.Left,
+		ValueTitle(v.title, ttl, v.fetchedAt, cached),
+		v.pageIndicator(),
+		decoderIndicator(v.decodeMode, v.decoded),
+		displayIndicator(v.hex, v.bits),
+		geoIndicator(v.showGeo(), v.suggestGeo()),
+		v.searchIndicator(),
+	)
 	container := defaultContainer
 	if st.ViewportActive() {
 		container = activeContainer
 	}
-	return container.Render(lipgloss.JoinVertical(lipgloss.Left, title, v.model.View()))
+	var body string
+	if v.search.active() {
+		v.model.Height-- // Make room for the search input
+		body = lipgloss.JoinVertical(lipgloss.Left, v.search.input.View(), v.model.View())
+	} else {
+		body = v.model.View()
+	}
+	if v.showTable() {
+		body = v.table.View(v.model.Width, v.model.Height)
+	}
+	return container.Render(lipgloss.JoinVertical(lipgloss.Left, title, body))
 }
 
 func (v Viewport) Update(msg tea.Msg, st state.AppState) (Viewport, tea.Cmd) {
 	var cmd tea.Cmd
 	if msg, ok := msg.(command.ValueUpdatedMsg); ok {
 		v.ttl = msg.TTL
-		v.model.SetContent(pretty(msg.NewValue))
+		v.cached = msg.Cached
+		if msg.Key == v.key && msg.FetchedAt.Equal(v.fetchedAt) {
+			// Same value served again from the cache (e.g. on refresh),
+			// keep the pages loaded so far and the scroll position
+			return v, nil
+		}
+		v.title = defaultTitle
+		v.report = false
+		v.syntax = ""
+		v.key = msg.Key
+		v.fetchedAt = msg.FetchedAt
 		v.value = msg.NewValue
-		return v, nil
+		v.valueType = msg.Type
+		v.collection = msg.Collection
+		v.loading = false
+		v.model.GotoTop()
+		v.table = v.table.Reset()
+		return v.render()
+	}
+
+	if msg, ok := msg.(command.ReportMsg); ok {
+		// The key and fetch time are kept, so that the report stays
+		// until another key is selected or the value is fetched again
+		v.title = msg.Title
+		v.report = true
+		v.syntax = msg.Syntax
+		v.value = msg.Body
+		v.valueType = ""
+		v.collection = nil
+		v.loading = false
+		v.model.GotoTop()
+		return v.render()
+	}
+
+	if msg, ok := msg.(command.ValuePageLoadedMsg); ok {
+		return v.appendPage(msg)
 	}
 
 	if !st.ViewportActive() {
@@ -66,24 +184,77 @@ func (v Viewport) Update(msg tea.Msg, st state.AppState) (Viewport, tea.Cmd) {
 	}
 
 	log.Print("Viewport is active, processing message...")
+	if v.showTable() && v.table.Searching() {
+		v.table, cmd = v.table.Update(msg)
+		return v, cmd
+	}
+
+	if v.search.typing && !v.showTable() {
+		return v.updateSearch(msg)
+	}
+
 	if msg, ok := msg.(tea.KeyMsg); ok {
 		switch msg.String() {
 		case "enter":
 			return v, state.DeactivateViewportCmd
 
+		case "d":
+			v.decodeMode = v.nextDecodeMode()
+			log.Printf("key 'd' pressed, decoding value with mode %s", v.decodeMode)
+			return v.render()
+
+		case "b":
+			v.display = (v.display + 1) % numDisplayModes
+			log.Printf("key 'b' pressed, displaying value with mode %s", v.display)
+			return v.render()
+
+		case "/":
+			if !v.showTable() {
+				return v.startSearch()
+			}
+
+		case "n", "N", "ctrl+t", "ctrl+r":
+			if !v.showTable() && v.search.query() != "" {
+				return v.updateSearch(msg)
+			}
+
+		case "g":
+			if v.collection != nil && v.collection.Type == "zset" {
+				v.geo[v.key] = !v.showGeo()
+				log.Printf("key 'g' pressed, geo view of %s: %v", v.key, v.geo[v.key])
+				return v.render()
+			}
+
+		case "t":
+			v.tableView = !v.tableView
+			log.Printf("key 't' pressed, table view: %v", v.tableView)
+			return v, nil
+
 		case "y":
-			return v, command.CopyValueToClipboard(context.Background(), v.value)
+			if !v.showTable() {
+				return v, command.RequestCopy(v.value)
+			}
 		}
 	}
 
-	v.model, cmd = v.model.Update(msg)
+	if v.showTable() {
+		v.table, cmd = v.table.Update(msg)
+	} else {
+		v.model, cmd = v.model.Update(msg)
+	}
+	if v.atBottom() {
+		var next tea.Cmd
+		v, next = v.requestNextPage()
+		cmd = tea.Batch(cmd, next)
+	}
 	return v, cmd
 }
 
-func ValueTitle(ttl int64) string {
+func ValueTitle(title string, ttl int64, fetchedAt time.Time, cached bool) string {
 	return lipgloss.JoinHorizontal(lipgloss.Left,
-		titleBarStyle.Render("VALUE"),
+		titleBarStyle.Render(title),
 		ttlIndicator(ttl),
+		cacheIndicator(fetchedAt, cached),
 	)
 }
 
@@ -97,22 +268,114 @@ func ttlIndicator(ttl int64) string {
 	return " (expires in " + strconv.FormatInt(ttl, 10) + " seconds)"
 }
 
-func pretty(s string) string {
-	var sb strings.Builder
-	tabCount := 0
-	for _, r := range s {
-		switch r {
-		case '{', '[':
-			tabCount++
-			sb.WriteString(fmt.Sprintf("%c\n%s", r, strings.Repeat("  ", tabCount)))
-		case '}', ']':
-			tabCount--
-			sb.WriteString(fmt.Sprintf("\n%s%c", strings.Repeat("  ", tabCount), r))
-		case ',':
-			sb.WriteString(",\n" + strings.Repeat("  ", tabCount))
-		default:
-			sb.WriteRune(r)
+// render decodes the value and sets the viewport content.
+func (v Viewport) render() (Viewport, tea.Cmd) {
+	res, err := v.decoders.Decode([]byte(v.value), v.decodeMode)
+	v.decoded = res.String()
+	if err != nil {
+		v.hex, v.bits = false, false
+		v.content = format.Printable(v.value)
+		v.plain = v.content
+		v = v.setContent()
+		return v, command.NewErrorInfoCmd(infoid.New(), err, 5*time.Second)
+	}
+
+	if v.collection != nil {
+		v.table = v.table.SetCollection(*v.collection)
+	}
+
+	v.hex = v.showHex(res.Data)
+	v.bits = v.valueType == "string" && v.display == DisplayBits
+	switch content, ok := format.JSON(string(res.Data)); {
+	case v.showGeo():
+		v.content = geoListing(*v.collection)
+		v.plain = v.content
+	case v.bits:
+		v.content = format.Bits(res.Data)
+		v.plain = v.content
+	case v.hex:
+		v.content = format.HexDump(res.Data)
+		v.plain = v.content
+	case v.syntax == command.SyntaxLua:
+		v.plain = format.Printable(string(res.Data))
+		v.content = format.Lua(v.plain)
+	case v.syntax == command.SyntaxStyled:
+		v.content = string(res.Data)
+		v.plain = ansi.Strip(v.content)
+	case ok:
+		v.content = content
+		v.plain, _ = format.IndentJSON(string(res.Data))
+	default:
+		v.content = format.Printable(content)
+		v.plain = v.content
+	}
+	v = v.setContent()
+	return v, nil
+}
+
+// showTable reports whether the value is displayed as a table rather than as text.
+func (v Viewport) showTable() bool {
+	return v.tableView && v.collection != nil && table.Supports(v.collection.Type) && !v.showGeo()
+}
+
+func (v Viewport) atBottom() bool {
+	if v.showTable() {
+		return v.table.AtBottom()
+	}
+	return v.model.AtBottom()
+}
+
+// showHex reports whether data should be displayed as a hex dump.
+// Only string values can be, as other types are rendered as JSON.
+func (v Viewport) showHex(data []byte) bool {
+	if v.valueType != "string" {
+		return false
+	}
+	switch v.display {
+	case DisplayHex:
+		return true
+	case DisplayText, DisplayBits:
+		return false
+	default:
+		return !utf8.Valid(data)
+	}
+}
+
+func (v Viewport) nextDecodeMode() string {
+	modes := v.decoders.Modes()
+	for i, m := range modes {
+		if m == v.decodeMode {
+			return modes[(i+1)%len(modes)]
 		}
 	}
-	return sb.String()
+	return decoder.ModeAuto
+}
+
+func decoderIndicator(mode, decoded string) string {
+	if mode == decoder.ModeAuto && decoded == "" {
+		return ""
+	}
+	if decoded == "" {
+		decoded = "-"
+	}
+	return cacheIndicatorStyle.Render(" [" + mode + ": " + decoded + "]")
+}
+
+func displayIndicator(hex, bits bool) string {
+	switch {
+	case hex:
+		return cacheIndicatorStyle.Render(" [hex]")
+	case bits:
+		return cacheIndicatorStyle.Render(" [bits]")
+	default:
+		return ""
+	}
+}
+
+func cacheIndicator(fetchedAt time.Time, cached bool) string {
+	if !cached {
+		return ""
+	}
+	ago := int64(time.Since(fetchedAt).Seconds())
+	return cacheIndicatorStyle.Render(" (cached " + strconv.FormatInt(ago, 10) + "s ago)")
 }
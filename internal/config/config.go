@@ -3,12 +3,26 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/redis/go-redis/v9"
 )
 
+const (
+	defaultPageSize      = 500
+	defaultMaxElements   = 10000
+	defaultOSC52MaxBytes = 100000
+)
+
 type Config struct {
-	Option *redis.Options
+	Option          *redis.Options
+	ProtoDescriptor string // Path to a FileDescriptorSet used to decode Protobuf values
+	ProtoMessage    string // Fully-qualified name of the Protobuf message stored in values
+	PageSize        int64  // Number of collection elements fetched at a time
+	MaxElements     int    // Maximum number of collection elements loaded in the viewport
+	Clipboard       string // Clipboard backend, empty to select one automatically
+	OSC52MaxBytes   int    // Maximum size of the encoded OSC 52 clipboard payload
+	ExportDir       string // Directory export files are written to
 }
 
 func GetConfigFromEnv() (*Config, error) {
@@ -24,5 +38,45 @@ func GetConfigFromEnv() (*Config, error) {
 		return nil, fmt.Errorf("failed to parse REDIS_URL: %w", err)
 	}
 
-	return &Config{Option: opt}, nil
+	pageSize, err := intFromEnv("RED_PAGE_SIZE", defaultPageSize)
+	if err != nil {
+		return nil, err
+	}
+	maxElements, err := intFromEnv("RED_MAX_ELEMENTS", defaultMaxElements)
+	if err != nil {
+		return nil, err
+	}
+
+	osc52MaxBytes, err := intFromEnv("RED_OSC52_MAX_BYTES", defaultOSC52MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	exportDir := os.Getenv("RED_EXPORT_DIR")
+	if exportDir == "" {
+		exportDir = "."
+	}
+
+	return &Config{
+		Option:          opt,
+		ProtoDescriptor: os.Getenv("RED_PROTO_DESCRIPTOR"),
+		ProtoMessage:    os.Getenv("RED_PROTO_MESSAGE"),
+		PageSize:        int64(pageSize),
+		MaxElements:     maxElements,
+		Clipboard:       os.Getenv("RED_CLIPBOARD"),
+		OSC52MaxBytes:   osc52MaxBytes,
+		ExportDir:       exportDir,
+	}, nil
+}
+
+func intFromEnv(name string, defaultValue int) (int, error) {
+	s := os.Getenv(name)
+	if s == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", name, s)
+	}
+	return n, nil
 }
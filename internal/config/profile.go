@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ProfilesPath returns the path of the connection profiles file: RED_PROFILES if set,
+// or red/profiles.json in the user configuration directory, e.g. ~/.config on Linux.
+func ProfilesPath() (string, error) {
+	if p := os.Getenv("RED_PROFILES"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "red", "profiles.json"), nil
+}
+
+// LoadProfiles reads the connection profiles, a JSON object mapping profile names
+// to Redis URLs, e.g. {"staging": "redis://staging:6379/0"}. A missing file means
+// there are no profiles.
+func LoadProfiles() (map[string]string, error) {
+	path, err := ProfilesPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var profiles map[string]string
+	if err := json.Unmarshal(b, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid profiles file %s: %w", path, err)
+	}
+	return profiles, nil
+}
+
+// Profile returns the connection options of a profile.
+func Profile(name string) (*redis.Options, error) {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return nil, err
+	}
+	url, ok := profiles[name]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown profile %q (profiles: %v)", name, names)
+	}
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL for profile %q: %w", name, err)
+	}
+	return opt, nil
+}
+
+// UseProfile connects to the server of a profile instead of REDIS_URL.
+func (c *Config) UseProfile(name string) error {
+	opt, err := Profile(name)
+	if err != nil {
+		return err
+	}
+	c.Option = opt
+	return nil
+}
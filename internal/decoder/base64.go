@@ -0,0 +1,50 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+)
+
+// minBase64Length avoids treating short words such as "test" as base64.
+const minBase64Length = 8
+
+// Base64 decodes standard or URL-safe base64, with or without padding.
+type Base64 struct {
+	// wraps detects the payloads that base64 is taken to wrap, gzip, Zstandard,
+	// MessagePack and JSON if nil.
+	wraps func([]byte) bool
+}
+
+func (Base64) Name() string { return "base64" }
+
+// Detect only accepts base64 that wraps something else we recognize,
+// as plenty of plain text happens to be valid base64.
+func (b Base64) Detect(data []byte) bool {
+	if len(data) < minBase64Length {
+		return false
+	}
+	out, err := b.Decode(data)
+	if err != nil {
+		return false
+	}
+	if b.wraps != nil {
+		return b.wraps(out)
+	}
+	return bytes.HasPrefix(out, gzipMagic) ||
+		bytes.HasPrefix(out, zstdMagic) ||
+		MessagePack{}.Detect(out) ||
+		json.Valid(out)
+}
+
+func (Base64) Decode(data []byte) ([]byte, error) {
+	s := string(bytes.TrimSpace(data))
+	var err error
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		var out []byte
+		if out, err = enc.DecodeString(s); err == nil {
+			return out, nil
+		}
+	}
+	return nil, err
+}
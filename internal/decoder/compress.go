@@ -0,0 +1,70 @@
+package decoder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxDecompressedSize protects the TUI against decompression bombs.
+const maxDecompressedSize = 64 << 20 // 64MB
+
+// ErrTooLarge is returned for data that decompresses to more than maxDecompressedSize,
+// rather than cutting it off.
+var ErrTooLarge = fmt.Errorf("decompressed value is larger than %dMB", maxDecompressedSize>>20)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Gzip decompresses gzip data.
+type Gzip struct{}
+
+func (Gzip) Name() string { return "gzip" }
+
+func (Gzip) Detect(data []byte) bool {
+	return bytes.HasPrefix(data, gzipMagic)
+}
+
+func (Gzip) Decode(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readAll(r)
+}
+
+// Zstd decompresses Zstandard data.
+type Zstd struct{}
+
+func (Zstd) Name() string { return "zstd" }
+
+func (Zstd) Detect(data []byte) bool {
+	return bytes.HasPrefix(data, zstdMagic)
+}
+
+func (Zstd) Decode(data []byte) ([]byte, error) {
+	r, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderMaxMemory(maxDecompressedSize))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readAll(r)
+}
+
+// readAll reads decompressed data, failing with ErrTooLarge past maxDecompressedSize.
+func readAll(r io.Reader) ([]byte, error) {
+	out, err := io.ReadAll(io.LimitReader(r, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxDecompressedSize {
+		return nil, ErrTooLarge
+	}
+	return out, nil
+}
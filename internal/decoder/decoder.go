@@ -0,0 +1,134 @@
+package decoder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// maxChainLength limits how many decoders can be applied to a single value.
+const maxChainLength = 8
+
+// Decoder transforms raw value bytes into a more readable representation.
+type Decoder interface {
+	// Name returns a short name displayed in the viewport title.
+	Name() string
+	// Detect reports whether data looks like something the decoder can decode.
+	Detect(data []byte) bool
+	// Decode decodes data.
+	Decode(data []byte) ([]byte, error)
+}
+
+// Result is the outcome of running a value through the pipeline.
+type Result struct {
+	Data  []byte   // Decoded data
+	Chain []string // Names of the decoders that were applied, in order
+}
+
+func (r Result) String() string {
+	return strings.Join(r.Chain, " → ")
+}
+
+// Pipeline chains decoders, either detected automatically or picked by the user.
+type Pipeline struct {
+	decoders []Decoder
+}
+
+// NewPipeline returns a pipeline with the built-in decoders, plus the given extra decoders
+// (e.g. Protobuf) which are tried last when auto-detecting.
+func NewPipeline(extra ...Decoder) *Pipeline {
+	p := &Pipeline{}
+	// Base64 is detected when it wraps anything the other decoders detect, extra ones included
+	p.decoders = append([]Decoder{Gzip{}, Zstd{}, MessagePack{}, Base64{wraps: p.detectWrapped}}, extra...)
+	return p
+}
+
+// Modes returns the decoding modes the user can pick from, starting with ModeAuto and ModeRaw.
+func (p *Pipeline) Modes() []string {
+	modes := []string{ModeAuto, ModeRaw}
+	for _, d := range p.decoders {
+		modes = append(modes, d.Name())
+	}
+	return modes
+}
+
+const (
+	ModeAuto = "auto" // Detect every decoder in the chain
+	ModeRaw  = "raw"  // Don't decode at all
+)
+
+// Decode runs data through the pipeline.
+// With ModeAuto every step is detected. With a decoder name that decoder is applied first,
+// and the following steps are detected, so that e.g. base64 → gzip → JSON can be chained.
+func (p *Pipeline) Decode(data []byte, mode string) (Result, error) {
+	res := Result{Data: data}
+	if mode == ModeRaw {
+		return res, nil
+	}
+
+	if mode != ModeAuto {
+		d := p.decoder(mode)
+		if d == nil {
+			return res, fmt.Errorf("unknown decoder %s", mode)
+		}
+		out, err := d.Decode(data)
+		if err != nil {
+			return res, fmt.Errorf("failed to decode value as %s: %w", d.Name(), err)
+		}
+		res.Data = out
+		res.Chain = append(res.Chain, d.Name())
+	}
+
+	for len(res.Chain) < maxChainLength {
+		d := p.detect(res.Data)
+		if d == nil {
+			break
+		}
+		out, err := d.Decode(res.Data)
+		if errors.Is(err, ErrTooLarge) {
+			// Not a detection mistake, the value is displayed undecoded with the reason
+			return res, fmt.Errorf("failed to decode value as %s: %w", d.Name(), err)
+		}
+		if err != nil {
+			log.Printf("Detected %s but failed to decode: %v", d.Name(), err)
+			break
+		}
+		res.Data = out
+		res.Chain = append(res.Chain, d.Name())
+	}
+	return res, nil
+}
+
+func (p *Pipeline) decoder(name string) Decoder {
+	for _, d := range p.decoders {
+		if d.Name() == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// detectWrapped reports whether data, decoded from base64, is JSON or something
+// that a decoder other than base64 detects.
+func (p *Pipeline) detectWrapped(data []byte) bool {
+	if json.Valid(data) {
+		return true
+	}
+	for _, d := range p.decoders {
+		if _, ok := d.(Base64); !ok && d.Detect(data) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Pipeline) detect(data []byte) Decoder {
+	for _, d := range p.decoders {
+		if d.Detect(data) {
+			return d
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MessagePack decodes a MessagePack document into JSON.
+type MessagePack struct{}
+
+func (MessagePack) Name() string { return "msgpack" }
+
+// Detect accepts data that starts with a map or array header and decodes completely,
+// as MessagePack has no magic bytes.
+func (MessagePack) Detect(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+	switch b := data[0]; {
+	case b >= 0x80 && b <= 0x9f: // fixmap, fixarray
+	case b >= 0xdc && b <= 0xdf: // array 16/32, map 16/32
+	default:
+		return false
+	}
+	_, err := decodeMessagePack(data)
+	return err == nil
+}
+
+func (MessagePack) Decode(data []byte) ([]byte, error) {
+	v, err := decodeMessagePack(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func decodeMessagePack(data []byte) (any, error) {
+	r := bytes.NewReader(data)
+	dec := msgpack.NewDecoder(r)
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (any, error) {
+		return d.DecodeUntypedMap()
+	})
+	v, err := dec.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("%d trailing bytes after MessagePack document", r.Len())
+	}
+	return jsonCompatible(v), nil
+}
+
+// jsonCompatible converts maps with non-string keys, which encoding/json can't marshal.
+func jsonCompatible(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonCompatible(e)
+		}
+		return m
+	case map[string]any:
+		for k, e := range v {
+			v[k] = jsonCompatible(e)
+		}
+		return v
+	case []any:
+		for i, e := range v {
+			v[i] = jsonCompatible(e)
+		}
+		return v
+	default:
+		return v
+	}
+}
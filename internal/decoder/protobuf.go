@@ -0,0 +1,68 @@
+package decoder
+
+import (
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Protobuf decodes a Protobuf message into JSON, using a user-supplied schema.
+type Protobuf struct {
+	desc protoreflect.MessageDescriptor
+}
+
+// NewProtobuf loads the message descriptor named message from a FileDescriptorSet file,
+// as produced by `protoc --include_imports --descriptor_set_out`.
+func NewProtobuf(descriptorSetPath, message string) (Protobuf, error) {
+	b, err := os.ReadFile(descriptorSetPath)
+	if err != nil {
+		return Protobuf{}, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+	var fds descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &fds); err != nil {
+		return Protobuf{}, fmt.Errorf("failed to parse descriptor set: %w", err)
+	}
+	files, err := protodesc.NewFiles(&fds)
+	if err != nil {
+		return Protobuf{}, fmt.Errorf("failed to load descriptor set: %w", err)
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(message))
+	if err != nil {
+		return Protobuf{}, fmt.Errorf("failed to find message %s: %w", message, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return Protobuf{}, fmt.Errorf("%s is not a message", message)
+	}
+	return Protobuf{desc: md}, nil
+}
+
+func (Protobuf) Name() string { return "protobuf" }
+
+// Detect accepts binary data that parses as the message without unknown fields,
+// as Protobuf has no magic bytes and almost anything is a valid message.
+func (p Protobuf) Detect(data []byte) bool {
+	if len(data) == 0 || utf8.Valid(data) {
+		return false
+	}
+	msg := dynamicpb.NewMessage(p.desc)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return false
+	}
+	return len(msg.GetUnknown()) == 0
+}
+
+func (p Protobuf) Decode(data []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(p.desc)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(msg)
+}
@@ -0,0 +1,180 @@
+// Package diff compares texts line by line and renders the differences side by side.
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Op tells which side of a comparison a line belongs to.
+type Op int
+
+const (
+	Equal  Op = iota // The line is on both sides
+	Delete           // The line is only on the left side
+	Insert           // The line is only on the right side
+)
+
+// Line is a line of an edit script.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+const (
+	// maxCells bounds the memory used by the longest common subsequence table.
+	maxCells = 4_000_000
+	// contextLines is the number of unchanged lines kept around changes.
+	contextLines = 3
+)
+
+// Lines returns the edit script turning a into b, based on their longest common subsequence.
+// Lines of very long texts that differ are compared as a whole block rather than one by one.
+func Lines(a, b []string) []Line {
+	// Common prefix and suffix don't need the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []Line
+	for _, s := range a[:prefix] {
+		lines = append(lines, Line{Equal, s})
+	}
+	lines = append(lines, lcs(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, s := range a[len(a)-suffix:] {
+		lines = append(lines, Line{Equal, s})
+	}
+	return lines
+}
+
+func lcs(a, b []string) []Line {
+	var lines []Line
+	if (len(a)+1)*(len(b)+1) > maxCells {
+		for _, s := range a {
+			lines = append(lines, Line{Delete, s})
+		}
+		for _, s := range b {
+			lines = append(lines, Line{Insert, s})
+		}
+		return lines
+	}
+
+	// n[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	w := len(b) + 1
+	n := make([]int32, (len(a)+1)*w)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				n[i*w+j] = n[(i+1)*w+j+1] + 1
+			} else {
+				n[i*w+j] = max(n[(i+1)*w+j], n[i*w+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, Line{Equal, a[i]})
+			i++
+			j++
+		case n[(i+1)*w+j] >= n[i*w+j+1]:
+			lines = append(lines, Line{Delete, a[i]})
+			i++
+		default:
+			lines = append(lines, Line{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, Line{Delete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, Line{Insert, b[j]})
+	}
+	return lines
+}
+
+// Changed reports whether the edit script has lines that are only on one side.
+func Changed(lines []Line) bool {
+	for _, l := range lines {
+		if l.Op != Equal {
+			return true
+		}
+	}
+	return false
+}
+
+// SideBySide renders the edit script in two columns fitting in width, the left side first.
+// Rows are marked with "-" or "+" for lines on one side only, and "~" for lines that
+// replace each other. Runs of unchanged lines are shortened to the lines around changes.
+func SideBySide(lines []Line, width int) string {
+	col := max((width-5)/2, 10) // Mark, space and separator
+	var sb strings.Builder
+	row := func(mark, left, right string) {
+		sb.WriteString(strings.TrimRight(mark+" "+cell(left, col)+" │ "+cell(right, col), " ") + "\n")
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].Op == Equal {
+			j := i
+			for j < len(lines) && lines[j].Op == Equal {
+				j++
+			}
+			// Keep the lines after the previous change and before the next one
+			head, tail := contextLines, contextLines
+			if i == 0 {
+				head = 0
+			}
+			if j == len(lines) {
+				tail = 0
+			}
+			if j-i > head+tail+1 {
+				for _, l := range lines[i : i+head] {
+					row(" ", l.Text, l.Text)
+				}
+				fmt.Fprintf(&sb, "  ⋯ %d unchanged lines\n", j-i-head-tail)
+				i = j - tail
+			}
+			for _, l := range lines[i:j] {
+				row(" ", l.Text, l.Text)
+			}
+			i = j
+			continue
+		}
+
+		// Pair the deleted lines with the inserted lines that follow them
+		var deleted, inserted []string
+		for ; i < len(lines) && lines[i].Op == Delete; i++ {
+			deleted = append(deleted, lines[i].Text)
+		}
+		for ; i < len(lines) && lines[i].Op == Insert; i++ {
+			inserted = append(inserted, lines[i].Text)
+		}
+		for k := range max(len(deleted), len(inserted)) {
+			switch {
+			case k >= len(inserted):
+				row("-", deleted[k], "")
+			case k >= len(deleted):
+				row("+", "", inserted[k])
+			default:
+				row("~", deleted[k], inserted[k])
+			}
+		}
+	}
+	return sb.String()
+}
+
+// cell truncates or pads s to exactly width columns.
+func cell(s string, width int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return runewidth.FillRight(runewidth.Truncate(s, width, "…"), width)
+}
@@ -0,0 +1,232 @@
+package dump
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hirotake111/redisclient/internal/source"
+	"github.com/hirotake111/redisclient/internal/values"
+	"github.com/redis/go-redis/v9"
+)
+
+// scanCount is the COUNT hint used when reading collections, so that huge keys are
+// read in batches rather than with a single HGETALL or LRANGE 0 -1.
+const scanCount = 1000
+
+// Fetch reads the key along with its type, TTL and full value.
+func Fetch(ctx context.Context, client *redis.Client, key string) (Record, error) {
+	t, err := client.Type(ctx, key).Result()
+	if err != nil {
+		return Record{}, err
+	}
+
+	var value any
+	switch t {
+	case "string":
+		var s string
+		s, err = client.Get(ctx, key).Result()
+		value = Bytes(s)
+	case "hash":
+		value, err = fetchHash(ctx, client, key)
+	case "list":
+		value, err = fetchList(ctx, client, key)
+	case "set":
+		value, err = fetchSet(ctx, client, key)
+	case "zset":
+		value, err = fetchZSet(ctx, client, key)
+	case "stream":
+		value, err = fetchStream(ctx, client, key)
+	case "none":
+		return Record{}, fmt.Errorf("key %q does not exist", key)
+	default:
+		return Record{}, fmt.Errorf("unsupported type %s for key %q", t, key)
+	}
+	if err != nil {
+		return Record{}, err
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return Record{}, err
+	}
+
+	ttl, err := client.PTTL(ctx, key).Result()
+	if err != nil {
+		return Record{}, err
+	}
+	rec := Record{Key: Bytes(key), Type: t, Value: b}
+	if ttl > 0 {
+		rec.TTL = ttl.Milliseconds()
+	}
+	return rec, nil
+}
+
+// FetchSource reads the key from a source that isn't a Redis server, e.g. an RDB file.
+// Streams are not supported.
+func FetchSource(ctx context.Context, src source.Source, key string) (Record, error) {
+	if client := src.Client(); client != nil {
+		return Fetch(ctx, client, key)
+	}
+
+	t, err := src.Type(ctx, key)
+	if err != nil {
+		return Record{}, err
+	}
+	var value any
+	switch t {
+	case "string":
+		var s string
+		s, err = src.Get(ctx, key)
+		value = Bytes(s)
+	case "hash", "list", "set", "zset":
+		value, err = fetchElements(ctx, src, key, t)
+	case "none":
+		return Record{}, fmt.Errorf("key %q does not exist", key)
+	default:
+		return Record{}, fmt.Errorf("unsupported type %s for key %q", t, key)
+	}
+	if err != nil {
+		return Record{}, err
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return Record{}, err
+	}
+	ttl, err := src.TTL(ctx, key)
+	if err != nil {
+		return Record{}, err
+	}
+	rec := Record{Key: Bytes(key), Type: t, Value: b}
+	if ttl > 0 {
+		rec.TTL = ttl.Milliseconds()
+	}
+	return rec, nil
+}
+
+// fetchElements reads every page of a collection, returning the value of its record.
+func fetchElements(ctx context.Context, src source.Source, key, t string) (any, error) {
+	var c values.Collection
+	for cursor := uint64(0); !c.Done; cursor = c.Cursor {
+		page, err := src.Page(ctx, key, t, cursor, scanCount)
+		if err != nil {
+			return nil, err
+		}
+		c = c.Append(page)
+	}
+	switch t {
+	case "hash":
+		fields := make([]Field, 0, len(c.Elements))
+		for _, e := range c.Elements {
+			fields = append(fields, Field{Field: Bytes(e.Field), Value: Bytes(e.Value)})
+		}
+		return fields, nil
+	case "zset":
+		members := make([]Member, 0, len(c.Elements))
+		for _, e := range c.Elements {
+			members = append(members, Member{Member: Bytes(e.Field), Score: e.Score})
+		}
+		return members, nil
+	default:
+		elements := make([]Bytes, 0, len(c.Elements))
+		for _, e := range c.Elements {
+			elements = append(elements, Bytes(e.Field))
+		}
+		return elements, nil
+	}
+}
+
+func fetchHash(ctx context.Context, client *redis.Client, key string) ([]Field, error) {
+	// HSCAN may return a field more than once
+	seen := make(map[string]bool)
+	fields := []Field{}
+	iter := client.HScan(ctx, key, 0, "", scanCount).Iterator()
+	for iter.Next(ctx) {
+		f := iter.Val()
+		if !iter.Next(ctx) {
+			break
+		}
+		if !seen[f] {
+			seen[f] = true
+			fields = append(fields, Field{Field: Bytes(f), Value: Bytes(iter.Val())})
+		}
+	}
+	return fields, iter.Err()
+}
+
+func fetchList(ctx context.Context, client *redis.Client, key string) ([]Bytes, error) {
+	elements := []Bytes{}
+	for start := int64(0); ; start += scanCount {
+		items, err := client.LRange(ctx, key, start, start+scanCount-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			elements = append(elements, Bytes(it))
+		}
+		if len(items) < scanCount {
+			return elements, nil
+		}
+	}
+}
+
+func fetchSet(ctx context.Context, client *redis.Client, key string) ([]Bytes, error) {
+	// SSCAN may return an element more than once
+	seen := make(map[string]bool)
+	elements := []Bytes{}
+	iter := client.SScan(ctx, key, 0, "", scanCount).Iterator()
+	for iter.Next(ctx) {
+		if m := iter.Val(); !seen[m] {
+			seen[m] = true
+			elements = append(elements, Bytes(m))
+		}
+	}
+	return elements, iter.Err()
+}
+
+func fetchZSet(ctx context.Context, client *redis.Client, key string) ([]Member, error) {
+	members := []Member{}
+	for start := int64(0); ; start += scanCount {
+		zs, err := client.ZRangeWithScores(ctx, key, start, start+scanCount-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, z := range zs {
+			members = append(members, Member{Member: Bytes(fmt.Sprint(z.Member)), Score: z.Score})
+		}
+		if len(zs) < scanCount {
+			return members, nil
+		}
+	}
+}
+
+// fetchStream reads the entries of a stream from the raw reply of XRANGE, a list of
+// [id, [field, value, ...]], as XRangeN returns the fields of entries in maps, which
+// loses their order.
+func fetchStream(ctx context.Context, client *redis.Client, key string) ([]StreamEntry, error) {
+	entries := []StreamEntry{}
+	start := "-"
+	for {
+		reply, err := client.Do(ctx, "XRANGE", key, start, "+", "COUNT", scanCount).Slice()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range reply {
+			entry, ok := r.([]any)
+			if !ok || len(entry) != 2 {
+				return nil, fmt.Errorf("unexpected XRANGE entry %v", r)
+			}
+			e := StreamEntry{ID: fmt.Sprint(entry[0])}
+			fields, _ := entry[1].([]any) // Nil for entries whose fields were deleted
+			for i := 0; i+1 < len(fields); i += 2 {
+				e.Fields = append(e.Fields, Field{Field: Bytes(fmt.Sprint(fields[i])), Value: Bytes(fmt.Sprint(fields[i+1]))})
+			}
+			entries = append(entries, e)
+		}
+		if len(reply) < scanCount {
+			return entries, nil
+		}
+		start = "(" + entries[len(entries)-1].ID // Exclusive range, Redis 6.2+
+	}
+}
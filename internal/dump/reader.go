@@ -0,0 +1,82 @@
+package dump
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Reader streams records from a JSON or NDJSON file, detecting the format from its first character.
+type Reader struct {
+	dec   *json.Decoder
+	array bool // Whether records are in a JSON array
+	line  int  // Index of the next record, for error messages
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	first, err := firstNonSpace(br)
+	if err != nil {
+		return nil, err
+	}
+
+	rd := &Reader{dec: json.NewDecoder(br), array: first == '['}
+	if rd.array {
+		if _, err := rd.dec.Token(); err != nil { // Consume '['
+			return nil, err
+		}
+	}
+	return rd, nil
+}
+
+// Next returns the next record, or io.EOF when there are no more records.
+// A record that can't be parsed is reported as an error, and reading can go on with the next one
+// as long as the file is still valid JSON.
+func (r *Reader) Next() (Record, error) {
+	if r.array && !r.dec.More() {
+		return Record{}, io.EOF
+	}
+
+	r.line++
+	var raw json.RawMessage
+	if err := r.dec.Decode(&raw); err != nil {
+		if err == io.EOF {
+			return Record{}, io.EOF
+		}
+		return Record{}, fmt.Errorf("record %d: %w", r.line, err)
+	}
+
+	var rec Record
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return Record{}, &RecordError{Index: r.line, Err: err}
+	}
+	if rec.Key == "" || rec.Type == "" {
+		return Record{}, &RecordError{Index: r.line, Err: fmt.Errorf("missing key or type")}
+	}
+	return rec, nil
+}
+
+// RecordError is returned for a record that is valid JSON but not a valid record.
+type RecordError struct {
+	Index int // 1-based index of the record in the file
+	Err   error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Index, e.Err)
+}
+
+func firstNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
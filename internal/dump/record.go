@@ -0,0 +1,155 @@
+package dump
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Bytes is binary-safe data. It is marshaled as a JSON string if it is valid UTF-8,
+// and as {"base64": "..."} otherwise, so that binary keys and values round-trip.
+type Bytes string
+
+type base64Bytes struct {
+	Base64 string `json:"base64"`
+}
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	if utf8.ValidString(string(b)) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(base64Bytes{Base64: base64.StdEncoding.EncodeToString([]byte(b))})
+}
+
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Bytes(s)
+		return nil
+	}
+	var bb base64Bytes
+	if err := json.Unmarshal(data, &bb); err != nil {
+		return fmt.Errorf("expected a string or {\"base64\": ...}: %w", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(bb.Base64)
+	if err != nil {
+		return err
+	}
+	*b = Bytes(decoded)
+	return nil
+}
+
+// Record is a key along with everything needed to recreate it.
+type Record struct {
+	Key   Bytes           `json:"key"`
+	Type  string          `json:"type"`
+	TTL   int64           `json:"ttl,omitempty"` // Remaining time to live in milliseconds, 0 if the key doesn't expire
+	Value json.RawMessage `json:"value"`
+}
+
+// Field is a field of a hash or of a stream entry.
+type Field struct {
+	Field Bytes `json:"field"`
+	Value Bytes `json:"value"`
+}
+
+// Member is a member of a sorted set. Scores are marshaled as JSON numbers, except
+// infinite ones, which JSON can't represent, marshaled as the strings "inf" and "-inf".
+type Member struct {
+	Member Bytes   `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// jsonMember is a Member as marshaled, with a number or a string as score.
+type jsonMember struct {
+	Member Bytes           `json:"member"`
+	Score  json.RawMessage `json:"score"`
+}
+
+func (m Member) MarshalJSON() ([]byte, error) {
+	var score []byte
+	switch {
+	case math.IsInf(m.Score, 1):
+		score = []byte(`"inf"`)
+	case math.IsInf(m.Score, -1):
+		score = []byte(`"-inf"`)
+	default:
+		var err error
+		if score, err = json.Marshal(m.Score); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(jsonMember{Member: m.Member, Score: score})
+}
+
+func (m *Member) UnmarshalJSON(data []byte) error {
+	var jm jsonMember
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return err
+	}
+	m.Member = jm.Member
+	var s string
+	if err := json.Unmarshal(jm.Score, &s); err != nil {
+		return json.Unmarshal(jm.Score, &m.Score)
+	}
+	switch strings.ToLower(s) {
+	case "inf", "+inf":
+		m.Score = math.Inf(1)
+	case "-inf":
+		m.Score = math.Inf(-1)
+	default:
+		return fmt.Errorf("invalid score %q, expected a number, \"inf\" or \"-inf\"", s)
+	}
+	return nil
+}
+
+// StreamEntry is an entry of a stream.
+type StreamEntry struct {
+	ID     string  `json:"id"`
+	Fields []Field `json:"fields"`
+}
+
+// The value of a record depends on its type:
+//
+//	string: Bytes
+//	hash:   []Field
+//	list:   []Bytes
+//	set:    []Bytes
+//	zset:   []Member
+//	stream: []StreamEntry
+
+func (r Record) String() (Bytes, error) {
+	var v Bytes
+	return v, r.decode(&v)
+}
+
+func (r Record) Hash() ([]Field, error) {
+	var v []Field
+	return v, r.decode(&v)
+}
+
+// Elements returns the elements of a list or set.
+func (r Record) Elements() ([]Bytes, error) {
+	var v []Bytes
+	return v, r.decode(&v)
+}
+
+func (r Record) ZSet() ([]Member, error) {
+	var v []Member
+	return v, r.decode(&v)
+}
+
+func (r Record) Stream() ([]StreamEntry, error) {
+	var v []StreamEntry
+	return v, r.decode(&v)
+}
+
+func (r Record) decode(v any) error {
+	if err := json.Unmarshal(r.Value, v); err != nil {
+		return fmt.Errorf("invalid %s value for key %q: %w", r.Type, r.Key, err)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package dump
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// argsPerCommand limits the size of the commands recreating large collections.
+	argsPerCommand = 1000
+	// maxRenameAttempts bounds the search for a free key name with PolicyRename.
+	maxRenameAttempts = 100
+)
+
+// Policy decides what happens when a key being imported already exists.
+type Policy string
+
+const (
+	PolicySkip      Policy = "skip"      // Keep the existing key
+	PolicyOverwrite Policy = "overwrite" // Replace the existing key
+	PolicyRename    Policy = "rename"    // Import the key under a new name with a suffix
+	PolicyAbort     Policy = "abort"     // Stop the import
+)
+
+// Policies lists every policy, e.g. for a selector.
+var Policies = []string{string(PolicySkip), string(PolicyOverwrite), string(PolicyRename), string(PolicyAbort)}
+
+// Actions taken, or that would be taken in a dry run, for a record.
+const (
+	ActionCreate    = "create"
+	ActionOverwrite = "overwrite"
+	ActionSkip      = "skip"
+	ActionRename    = "rename"
+	ActionAbort     = "abort"
+	ActionFail      = "fail"
+)
+
+// Result is the outcome of importing a record.
+type Result struct {
+	Key    Bytes  // Key of the record
+	Target Bytes  // Key the record is written to, which differs from Key when renamed
+	Action string // One of the Action constants
+	Err    error  // Why the record failed, for ActionFail and ActionAbort
+}
+
+// Importer recreates records in a database.
+type Importer struct {
+	client *redis.Client
+	policy Policy
+	dryRun bool
+	suffix string          // Appended to the names of renamed keys
+	taken  map[string]bool // Keys written by the batch, or that would be by the dry run
+}
+
+func NewImporter(client *redis.Client, policy Policy, dryRun bool) *Importer {
+	return &Importer{client: client, policy: policy, dryRun: dryRun, suffix: ":imported", taken: map[string]bool{}}
+}
+
+// Import imports a batch of records, pipelining the commands of every record.
+// It returns a result per processed record, and whether the import must stop
+// because a key exists and the policy is PolicyAbort. Keys written by earlier records
+// of the import conflict with later records like keys that already existed.
+func (im *Importer) Import(ctx context.Context, recs []Record) ([]Result, bool, error) {
+	exists, err := im.exist(ctx, recs)
+	if err != nil {
+		return nil, false, err
+	}
+	// Keys written by earlier batches exist, unless nothing is written
+	if !im.dryRun {
+		clear(im.taken)
+	}
+
+	results := make([]Result, 0, len(recs))
+	aborted := false
+	for i, rec := range recs {
+		res := Result{Key: rec.Key, Target: rec.Key, Action: ActionCreate}
+		if exists[i] || im.taken[string(rec.Key)] {
+			switch im.policy {
+			case PolicySkip:
+				res.Action = ActionSkip
+			case PolicyOverwrite:
+				res.Action = ActionOverwrite
+			case PolicyRename:
+				res.Action = ActionRename
+				res.Target, err = im.freeName(ctx, rec.Key)
+				if err != nil {
+					res.Action, res.Err = ActionFail, err
+				}
+			default:
+				res.Action = ActionAbort
+				res.Err = fmt.Errorf("key already exists")
+				aborted = true
+			}
+		}
+		if res.Action != ActionSkip && res.Err == nil {
+			// Validate the value even in a dry run
+			if _, err := commands(rec, string(res.Target)); err != nil {
+				res.Action, res.Err = ActionFail, err
+			}
+		}
+		if res.Action != ActionSkip && res.Err == nil {
+			im.taken[string(res.Target)] = true
+		}
+		results = append(results, res)
+		if aborted {
+			break
+		}
+	}
+
+	if im.dryRun {
+		return results, aborted, nil
+	}
+	return im.write(ctx, recs, results), aborted, nil
+}
+
+func (im *Importer) exist(ctx context.Context, recs []Record) ([]bool, error) {
+	keys := make([]string, len(recs))
+	for i, rec := range recs {
+		keys[i] = string(rec.Key)
+	}
+	return exist(ctx, im.client, keys)
+}
+
+// exist reports whether each key exists, with a single pipeline.
+func exist(ctx context.Context, client *redis.Client, keys []string) ([]bool, error) {
+	pipe := client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, k := range keys {
+		cmds[i] = pipe.Exists(ctx, k)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to check existing keys: %w", err)
+	}
+	exists := make([]bool, len(keys))
+	for i, c := range cmds {
+		exists[i] = c.Val() > 0
+	}
+	return exists, nil
+}
+
+// freeName returns the first name made of key and the suffix that isn't taken, in the
+// database or by the import, e.g. "user:1:imported" then "user:1:imported:2".
+func (im *Importer) freeName(ctx context.Context, key Bytes) (Bytes, error) {
+	for i := 1; i <= maxRenameAttempts; i++ {
+		name := string(key) + im.suffix
+		if i > 1 {
+			name += ":" + strconv.Itoa(i)
+		}
+		if im.taken[name] {
+			continue
+		}
+		n, err := im.client.Exists(ctx, name).Result()
+		if err != nil {
+			return "", err
+		}
+		if n == 0 {
+			return Bytes(name), nil
+		}
+	}
+	return "", fmt.Errorf("no free name found after %d attempts", maxRenameAttempts)
+}
+
+// write pipelines the commands of every record that must be written,
+// and reports the first error of each record.
+func (im *Importer) write(ctx context.Context, recs []Record, results []Result) []Result {
+	pipe := im.client.Pipeline()
+	cmds := make([][]redis.Cmder, len(results))
+	for i, res := range results {
+		if res.Err != nil || res.Action == ActionSkip {
+			continue
+		}
+		target := string(res.Target)
+		if res.Action == ActionOverwrite {
+			cmds[i] = append(cmds[i], pipe.Del(ctx, target))
+		}
+		args, _ := commands(recs[i], target) // Already validated
+		for _, a := range args {
+			cmds[i] = append(cmds[i], pipe.Do(ctx, a...))
+		}
+	}
+	// Errors are checked per record below
+	_, _ = pipe.Exec(ctx)
+
+	for i := range results {
+		for _, c := range cmds[i] {
+			if err := c.Err(); err != nil {
+				log.Printf("Failed to import key %q: %v", results[i].Key, err)
+				results[i].Action, results[i].Err = ActionFail, err
+				break
+			}
+		}
+	}
+	return results
+}
+
+// commands returns the commands recreating the record as target.
+func commands(rec Record, target string) ([][]any, error) {
+	var cmds [][]any
+	switch rec.Type {
+	case "string":
+		v, err := rec.String()
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, []any{"SET", target, string(v)})
+
+	case "hash":
+		fields, err := rec.Hash()
+		if err != nil {
+			return nil, err
+		}
+		args := make([]any, 0, len(fields)*2)
+		for _, f := range fields {
+			args = append(args, string(f.Field), string(f.Value))
+		}
+		cmds = chunk(cmds, []any{"HSET", target}, args, 2)
+
+	case "list", "set":
+		elements, err := rec.Elements()
+		if err != nil {
+			return nil, err
+		}
+		args := make([]any, 0, len(elements))
+		for _, e := range elements {
+			args = append(args, string(e))
+		}
+		name := "RPUSH"
+		if rec.Type == "set" {
+			name = "SADD"
+		}
+		cmds = chunk(cmds, []any{name, target}, args, 1)
+
+	case "zset":
+		members, err := rec.ZSet()
+		if err != nil {
+			return nil, err
+		}
+		args := make([]any, 0, len(members)*2)
+		for _, m := range members {
+			args = append(args, m.Score, string(m.Member))
+		}
+		cmds = chunk(cmds, []any{"ZADD", target}, args, 2)
+
+	case "stream":
+		entries, err := rec.Stream()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			args := []any{"XADD", target, e.ID}
+			for _, f := range e.Fields {
+				args = append(args, string(f.Field), string(f.Value))
+			}
+			cmds = append(cmds, args)
+		}
+		if len(entries) == 0 {
+			// Streams can be empty, adding an entry with MAXLEN 0 creates one
+			cmds = append(cmds, []any{"XADD", target, "MAXLEN", "0", "*", "_", "_"})
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported type %s", rec.Type)
+	}
+
+	if len(cmds) == 0 {
+		return nil, fmt.Errorf("empty %s can't be created", rec.Type)
+	}
+	if rec.TTL > 0 {
+		cmds = append(cmds, []any{"PEXPIRE", target, rec.TTL})
+	}
+	return cmds, nil
+}
+
+// chunk appends commands made of prefix and args, split so that no command
+// has more than argsPerCommand arguments. step keeps pairs together.
+func chunk(cmds [][]any, prefix, args []any, step int) [][]any {
+	size := argsPerCommand - argsPerCommand%step
+	for start := 0; start < len(args); start += size {
+		end := min(start+size, len(args))
+		cmd := append(append(make([]any, 0, len(prefix)+end-start), prefix...), args[start:end]...)
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
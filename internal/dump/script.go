@@ -0,0 +1,197 @@
+package dump
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Commands returns the commands recreating the record under its own key, preceded by a DEL
+// so that the commands can be replayed against a database that already has the key.
+func Commands(rec Record) ([][]string, error) {
+	cmds, err := commands(rec, string(rec.Key))
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]string, 0, len(cmds)+1)
+	out = append(out, []string{"DEL", string(rec.Key)})
+	for _, c := range cmds {
+		args := make([]string, len(c))
+		for i, a := range c {
+			args[i] = argString(a)
+		}
+		out = append(out, args)
+	}
+	return out, nil
+}
+
+func argString(a any) string {
+	switch v := a.(type) {
+	case string:
+		return v
+	case float64:
+		switch {
+		case math.IsInf(v, 1):
+			return "+inf"
+		case math.IsInf(v, -1):
+			return "-inf"
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// InlineCommand formats a command in redis-cli syntax, one command per line.
+// Arguments are double-quoted when needed, escaping binary and multiline content.
+func InlineCommand(args []string) string {
+	var sb strings.Builder
+	for i, a := range args {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(quote(a))
+	}
+	return sb.String()
+}
+
+// RESPCommand formats a command as a RESP array of bulk strings, as expected by redis-cli --pipe.
+func RESPCommand(args []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(a), a)
+	}
+	return sb.String()
+}
+
+// quote quotes s the way redis-cli parses double-quoted arguments, leaving
+// arguments made only of printable, non-space ASCII characters as they are.
+func quote(s string) string {
+	if s != "" && !needsQuotes(s) {
+		return s
+	}
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\a':
+			sb.WriteString(`\a`)
+		case '\b':
+			sb.WriteString(`\b`)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				fmt.Fprintf(&sb, `\x%02x`, c)
+			} else {
+				sb.WriteByte(c)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func needsQuotes(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c >= 0x7f || c == '"' || c == '\'' || c == '\\' {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitArgs splits a line into arguments the way redis-cli does, the reverse of InlineCommand.
+// Double-quoted arguments may contain escapes, and single-quoted arguments only \'.
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	for i := 0; ; {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return args, nil
+		}
+
+		var sb strings.Builder
+		switch q := line[i]; q {
+		case '"', '\'':
+			i++
+			for {
+				if i >= len(line) {
+					return nil, fmt.Errorf("unbalanced quotes in %q", line)
+				}
+				c := line[i]
+				if c == q {
+					i++
+					break
+				}
+				if c == '\\' && i+1 < len(line) {
+					if q == '\'' {
+						if line[i+1] == '\'' {
+							c = '\''
+							i++
+						}
+					} else {
+						var n int
+						c, n = unescape(line[i+1:])
+						i += n
+					}
+				}
+				sb.WriteByte(c)
+				i++
+			}
+			// The closing quote must end the argument
+			if i < len(line) && !isSpace(line[i]) {
+				return nil, fmt.Errorf("closing quote must be followed by a space in %q", line)
+			}
+		default:
+			for i < len(line) && !isSpace(line[i]) {
+				sb.WriteByte(line[i])
+				i++
+			}
+		}
+		args = append(args, sb.String())
+	}
+}
+
+// unescape decodes the escape sequence at the start of s, after a backslash,
+// returning the byte and the length of the sequence. Unknown escapes stand for
+// the escaped character itself, e.g. \" for ".
+func unescape(s string) (byte, int) {
+	switch s[0] {
+	case 'n':
+		return '\n', 1
+	case 'r':
+		return '\r', 1
+	case 't':
+		return '\t', 1
+	case 'a':
+		return '\a', 1
+	case 'b':
+		return '\b', 1
+	case 'x':
+		if len(s) >= 3 {
+			if b, err := strconv.ParseUint(s[1:3], 16, 8); err == nil {
+				return byte(b), 3
+			}
+		}
+	}
+	return s[0], 1
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,188 @@
+package dump
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Methods used to copy keys between servers.
+const (
+	MethodAuto    = "auto"         // MIGRATE when possible, DUMP and RESTORE otherwise
+	MethodRestore = "dump/restore" // DUMP and RESTORE only
+)
+
+// Methods lists every method, e.g. for a selector.
+var Methods = []string{MethodAuto, MethodRestore}
+
+// SyncPolicies lists the policies supported when copying keys between servers.
+var SyncPolicies = []string{string(PolicySkip), string(PolicyOverwrite)}
+
+// migrateTimeout bounds the time the source server waits for the target when running MIGRATE.
+const migrateTimeout = 5 * time.Second
+
+// Syncer copies keys with their type, value and TTL from a Redis server to another,
+// or to another database of the same server.
+type Syncer struct {
+	src, dst *redis.Client
+	policy   Policy
+	migrate  bool // Whether MIGRATE is tried, which stops after its first failure
+}
+
+func NewSyncer(src, dst *redis.Client, policy Policy, method string) *Syncer {
+	return &Syncer{src: src, dst: dst, policy: policy, migrate: method == MethodAuto && canMigrate(src.Options(), dst.Options())}
+}
+
+// canMigrate reports whether the source server can run MIGRATE to the target. MIGRATE
+// connects from the source server to the address of the target as seen from this
+// machine, without TLS. Loopback addresses would designate another server, and a server
+// connecting to itself would block until the timeout.
+func canMigrate(src, dst *redis.Options) bool {
+	if src.Addr == dst.Addr || dst.TLSConfig != nil {
+		return false
+	}
+	srcHost, _, err := net.SplitHostPort(src.Addr)
+	if err != nil {
+		return false
+	}
+	dstHost, _, err := net.SplitHostPort(dst.Addr)
+	if err != nil {
+		return false
+	}
+	return !isLoopback(dstHost) || isLoopback(srcHost)
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Sync copies a batch of keys, pipelining the commands, and returns a result per key.
+func (s *Syncer) Sync(ctx context.Context, keys []string) ([]Result, error) {
+	exists, err := exist(ctx, s.dst, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, len(keys))
+	var pending []int // Indexes of the keys to copy
+	for i, k := range keys {
+		results[i] = Result{Key: Bytes(k), Target: Bytes(k), Action: ActionCreate}
+		if exists[i] {
+			if s.policy == PolicySkip {
+				results[i].Action = ActionSkip
+				continue
+			}
+			results[i].Action = ActionOverwrite
+		}
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	replace := s.policy == PolicyOverwrite
+	if s.migrate {
+		err := s.migrateKeys(ctx, keys, pending)
+		if err == nil {
+			return results, nil
+		}
+		log.Printf("MIGRATE failed, falling back to DUMP and RESTORE: %v", err)
+		s.migrate = false
+		// Some keys may have been copied before the failure
+		replace = true
+	}
+	s.restore(ctx, keys, pending, results, replace)
+	return results, nil
+}
+
+// migrateKeys copies the keys at the pending indexes with a single MIGRATE, keeping them in the source.
+func (s *Syncer) migrateKeys(ctx context.Context, keys []string, pending []int) error {
+	opt := s.dst.Options()
+	host, port, err := net.SplitHostPort(opt.Addr)
+	if err != nil {
+		return err
+	}
+	args := []any{"MIGRATE", host, port, "", opt.DB, migrateTimeout.Milliseconds(), "COPY"}
+	if s.policy == PolicyOverwrite {
+		args = append(args, "REPLACE")
+	}
+	switch {
+	case opt.Username != "":
+		args = append(args, "AUTH2", opt.Username, opt.Password)
+	case opt.Password != "":
+		args = append(args, "AUTH", opt.Password)
+	}
+	args = append(args, "KEYS")
+	for _, i := range pending {
+		args = append(args, keys[i])
+	}
+	// NOKEY is a successful reply when every key has vanished
+	return s.src.Do(ctx, args...).Err()
+}
+
+// restore copies the keys at the pending indexes with DUMP and RESTORE,
+// and reports the error of each key in results.
+func (s *Syncer) restore(ctx context.Context, keys []string, pending []int, results []Result, replace bool) {
+	pipe := s.src.Pipeline()
+	dumps := make([]*redis.StringCmd, len(pending))
+	ttls := make([]*redis.DurationCmd, len(pending))
+	for j, i := range pending {
+		dumps[j] = pipe.Dump(ctx, keys[i])
+		ttls[j] = pipe.PTTL(ctx, keys[i])
+	}
+	// Errors are checked per key below
+	_, _ = pipe.Exec(ctx)
+
+	pipe = s.dst.Pipeline()
+	restores := make([]*redis.StatusCmd, len(pending))
+	for j, i := range pending {
+		payload, err := dumps[j].Result()
+		if err == nil {
+			err = ttls[j].Err()
+		}
+		if errors.Is(err, redis.Nil) || ttls[j].Val() == -2 {
+			err = errors.New("key no longer exists")
+		}
+		if err != nil {
+			results[i].Action, results[i].Err = ActionFail, err
+			continue
+		}
+		ttl := max(ttls[j].Val(), 0) // -1 if the key doesn't expire
+		if replace {
+			restores[j] = pipe.RestoreReplace(ctx, keys[i], ttl, payload)
+		} else {
+			restores[j] = pipe.Restore(ctx, keys[i], ttl, payload)
+		}
+	}
+	_, _ = pipe.Exec(ctx)
+
+	for j, i := range pending {
+		if restores[j] == nil {
+			continue
+		}
+		err := restores[j].Err()
+		switch {
+		case err == nil:
+		case strings.HasPrefix(err.Error(), "BUSYKEY"):
+			// Created since the keys were checked
+			results[i].Action = ActionSkip
+		case strings.Contains(err.Error(), "payload version"):
+			results[i].Action, results[i].Err = ActionFail, fmt.Errorf("%w (the target server is older than the source)", err)
+		default:
+			results[i].Action, results[i].Err = ActionFail, err
+		}
+		if results[i].Err != nil {
+			log.Printf("Failed to copy key %q: %v", keys[i], results[i].Err)
+		}
+	}
+}
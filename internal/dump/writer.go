@@ -0,0 +1,91 @@
+package dump
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// File formats.
+const (
+	FormatJSON   = "json"   // A single JSON array of records
+	FormatNDJSON = "ndjson" // One record per line
+	FormatInline = "redis"  // redis-cli commands recreating the records, one per line
+	FormatRESP   = "resp"   // The same commands in RESP, for redis-cli --pipe
+)
+
+// Writer streams records to a file, so that large keyspaces don't need to fit in memory.
+type Writer struct {
+	w      *bufio.Writer
+	format string
+	count  int
+}
+
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	switch format {
+	case FormatJSON, FormatNDJSON, FormatInline, FormatRESP:
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	wr := &Writer{w: bufio.NewWriter(w), format: format}
+	if format == FormatJSON {
+		if _, err := wr.w.WriteString("[\n"); err != nil {
+			return nil, err
+		}
+	}
+	return wr, nil
+}
+
+func (w *Writer) Write(rec Record) error {
+	if w.format == FormatInline || w.format == FormatRESP {
+		return w.writeCommands(rec)
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if w.format == FormatJSON && w.count > 0 {
+		if _, err := w.w.WriteString(",\n"); err != nil {
+			return err
+		}
+	}
+	if _, err := w.w.Write(b); err != nil {
+		return err
+	}
+	if w.format == FormatNDJSON {
+		if err := w.w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	w.count++
+	return nil
+}
+
+// Close terminates the document and flushes buffered records. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.format == FormatJSON {
+		if _, err := w.w.WriteString("\n]\n"); err != nil {
+			return err
+		}
+	}
+	return w.w.Flush()
+}
+
+func (w *Writer) writeCommands(rec Record) error {
+	cmds, err := Commands(rec)
+	if err != nil {
+		return err
+	}
+	for _, c := range cmds {
+		line := RESPCommand(c)
+		if w.format == FormatInline {
+			line = InlineCommand(c) + "\n"
+		}
+		if _, err := w.w.WriteString(line); err != nil {
+			return err
+		}
+	}
+	w.count++
+	return nil
+}
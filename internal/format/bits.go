@@ -0,0 +1,81 @@
+package format
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+const (
+	// bytesPerRow is the number of bytes of a row of the bit grid, 32 bits.
+	bytesPerRow = 4
+	// maxBitsBytes is the number of bytes of the bit grid at most, 16384 rows.
+	maxBitsBytes = 64 * 1024
+)
+
+// Bits renders data as a grid of bits, most significant bit first like SETBIT and GETBIT
+// address them, with the offset of the first bit of each row. Set bits are 1s and clear
+// bits dots. The grid is preceded by the results of BITCOUNT and BITPOS on data.
+func Bits(data []byte) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d bits, BITCOUNT %d, BITPOS 1 → %d, BITPOS 0 → %d\n\n", len(data)*8, BitCount(data), BitPos(data, 1), BitPos(data, 0))
+	if len(data) == 0 {
+		return strings.TrimSuffix(sb.String(), "\n\n")
+	}
+
+	shown := data
+	if len(shown) > maxBitsBytes {
+		shown = shown[:maxBitsBytes]
+	}
+	width := len(fmt.Sprint((len(shown) - 1) * 8))
+	ruler := strings.Repeat(" ", width)
+	for i := range bytesPerRow {
+		ruler += fmt.Sprintf("  %-8s", fmt.Sprintf("+%d", i*8))
+	}
+	sb.WriteString(strings.TrimRight(ruler, " ") + "\n")
+	for row := 0; row < len(shown); row += bytesPerRow {
+		fmt.Fprintf(&sb, "%*d", width, row*8)
+		for _, b := range shown[row:min(row+bytesPerRow, len(shown))] {
+			sb.WriteString("  ")
+			for i := 7; i >= 0; i-- {
+				if b&(1<<i) != 0 {
+					sb.WriteByte('1')
+				} else {
+					sb.WriteByte('.')
+				}
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	if len(shown) < len(data) {
+		fmt.Fprintf(&sb, "\n(the first %d of %d bytes are shown)\n", len(shown), len(data))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// BitCount counts the set bits of data, like BITCOUNT.
+func BitCount(data []byte) int {
+	n := 0
+	for _, b := range data {
+		n += bits.OnesCount8(b)
+	}
+	return n
+}
+
+// BitPos returns the offset of the first bit of data set to bit, like BITPOS. Like Redis,
+// it returns -1 if no bit is set, and the offset right after data if every bit is set
+// when looking for a clear bit.
+func BitPos(data []byte, bit int) int {
+	for i, b := range data {
+		if bit == 0 {
+			b = ^b
+		}
+		if b != 0 {
+			return i*8 + bits.LeadingZeros8(b)
+		}
+	}
+	if bit == 0 {
+		return len(data) * 8
+	}
+	return -1
+}
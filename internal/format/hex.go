@@ -0,0 +1,11 @@
+package format
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// HexDump renders data as offsets, hex bytes and printable characters, like `hexdump -C`.
+func HexDump(data []byte) string {
+	return strings.TrimSuffix(hex.Dump(data), "\n")
+}
@@ -0,0 +1,169 @@
+package format
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hirotake111/redisclient/internal/color"
+)
+
+const indent = "  "
+
+var (
+	jsonKeyStyle     = lipgloss.NewStyle().Foreground(color.Primary)
+	jsonStringStyle  = lipgloss.NewStyle().Foreground(color.JSONString)
+	jsonNumberStyle  = lipgloss.NewStyle().Foreground(color.JSONNumber)
+	jsonBooleanStyle = lipgloss.NewStyle().Foreground(color.Warning)
+	jsonNullStyle    = lipgloss.NewStyle().Foreground(color.Grey)
+)
+
+// JSON indents and colors s if it is a valid JSON document.
+// It returns s as is and false otherwise.
+func JSON(s string) (string, bool) {
+	return formatJSON(s, true)
+}
+
+// IndentJSON indents s without coloring it if it is a valid JSON document.
+// It returns s as is and false otherwise.
+func IndentJSON(s string) (string, bool) {
+	return formatJSON(s, false)
+}
+
+func formatJSON(s string, colored bool) (string, bool) {
+	if !json.Valid([]byte(s)) {
+		return s, false
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s) * 2)
+	f := formatter{sb: &sb, colored: colored}
+	f.format(s)
+	return sb.String(), true
+}
+
+type formatter struct {
+	sb      *strings.Builder
+	colored bool
+	depth   int
+	// Whether each enclosing container is an object, innermost last
+	objects []bool
+	// Whether the next string in the innermost object is a key
+	expectKey bool
+}
+
+// format writes the tokens of s, which must be valid JSON.
+func (f *formatter) format(s string) {
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			i++
+
+		case '{', '[':
+			// Render empty containers on a single line
+			if j := skipSpaces(s, i+1); j < len(s) && (s[j] == '}' || s[j] == ']') {
+				f.sb.WriteByte(c)
+				f.sb.WriteByte(s[j])
+				i = j + 1
+				continue
+			}
+			f.sb.WriteByte(c)
+			f.depth++
+			f.objects = append(f.objects, c == '{')
+			f.expectKey = c == '{'
+			f.newline()
+			i++
+
+		case '}', ']':
+			f.depth--
+			f.objects = f.objects[:len(f.objects)-1]
+			f.newline()
+			f.sb.WriteByte(c)
+			i++
+
+		case ',':
+			f.sb.WriteByte(c)
+			f.expectKey = f.inObject()
+			f.newline()
+			i++
+
+		case ':':
+			f.sb.WriteString(": ")
+			f.expectKey = false
+			i++
+
+		case '"':
+			j := endOfString(s, i)
+			if f.expectKey {
+				f.token(jsonKeyStyle, s[i:j])
+			} else {
+				f.token(jsonStringStyle, s[i:j])
+			}
+			i = j
+
+		default:
+			j := endOfLiteral(s, i)
+			switch lit := s[i:j]; lit {
+			case "true", "false":
+				f.token(jsonBooleanStyle, lit)
+			case "null":
+				f.token(jsonNullStyle, lit)
+			default:
+				f.token(jsonNumberStyle, lit)
+			}
+			i = j
+		}
+	}
+}
+
+func (f *formatter) token(style lipgloss.Style, text string) {
+	if f.colored {
+		f.sb.WriteString(style.Render(text))
+	} else {
+		f.sb.WriteString(text)
+	}
+}
+
+func (f *formatter) inObject() bool {
+	return len(f.objects) > 0 && f.objects[len(f.objects)-1]
+}
+
+func (f *formatter) newline() {
+	f.sb.WriteByte('\n')
+	for range f.depth {
+		f.sb.WriteString(indent)
+	}
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// endOfString returns the index right after the closing quote of the string starting at i.
+func endOfString(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++ // Skip the escaped character
+		case '"':
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+// endOfLiteral returns the index right after the number, boolean or null starting at i.
+func endOfLiteral(s string, i int) int {
+	for i < len(s) {
+		switch s[i] {
+		case ',', '}', ']', ' ', '\t', '\n', '\r':
+			return i
+		}
+		i++
+	}
+	return i
+}
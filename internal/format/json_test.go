@@ -0,0 +1,103 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestIndentJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "scalars",
+			in:   `42`,
+			want: `42`,
+		},
+		{
+			name: "empty containers",
+			in:   `{"a": { }, "b":[ ]}`,
+			want: "{\n  \"a\": {},\n  \"b\": []\n}",
+		},
+		{
+			name: "nested",
+			in:   `{"user":{"name":"ann","tags":["a",["b",{"c":null}]],"age":3.5e2,"admin":false}}`,
+			want: `{
+  "user": {
+    "name": "ann",
+    "tags": [
+      "a",
+      [
+        "b",
+        {
+          "c": null
+        }
+      ]
+    ],
+    "age": 3.5e2,
+    "admin": false
+  }
+}`,
+		},
+		{
+			name: "escaped",
+			in:   `{"quote\"key":"a \"b\" c","path":"C:\\dir\\","brackets":"{[,:]}","newline":"a\nb\u0000"}`,
+			want: `{
+  "quote\"key": "a \"b\" c",
+  "path": "C:\\dir\\",
+  "brackets": "{[,:]}",
+  "newline": "a\nb\u0000"
+}`,
+		},
+		{
+			name: "unicode",
+			in:   `{"日本語":"こんにちは 🌍","emoji":["😀","👩‍👩‍👧"],"escaped":"\u00e9\ud83d\ude00","accents":"Ωmega ñ ü"}`,
+			want: `{
+  "日本語": "こんにちは 🌍",
+  "emoji": [
+    "😀",
+    "👩‍👩‍👧"
+  ],
+  "escaped": "\u00e9\ud83d\ude00",
+  "accents": "Ωmega ñ ü"
+}`,
+		},
+		{
+			name: "whitespace",
+			in:   "\n\t[ 1 ,\r\n 2 ]  ",
+			want: "[\n  1,\n  2\n]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := IndentJSON(tt.in)
+			if !ok {
+				t.Fatalf("IndentJSON(%q) is not valid JSON", tt.in)
+			}
+			if got != tt.want {
+				t.Errorf("IndentJSON(%q) =\n%s\nwant\n%s", tt.in, got, tt.want)
+			}
+			colored, ok := JSON(tt.in)
+			if !ok {
+				t.Fatalf("JSON(%q) is not valid JSON", tt.in)
+			}
+			if plain := ansi.Strip(colored); plain != tt.want {
+				t.Errorf("JSON(%q) without colors =\n%s\nwant\n%s", tt.in, plain, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONInvalid(t *testing.T) {
+	for _, in := range []string{``, `{`, `{"a":}`, `not json`, `{"a":1}}`} {
+		if got, ok := JSON(in); ok || got != in {
+			t.Errorf("JSON(%q) = %q, %v, want it as is and false", in, got, ok)
+		}
+		if got, ok := IndentJSON(in); ok || got != in {
+			t.Errorf("IndentJSON(%q) = %q, %v, want it as is and false", in, got, ok)
+		}
+	}
+}
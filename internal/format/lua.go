@@ -0,0 +1,154 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hirotake111/redisclient/internal/color"
+)
+
+var (
+	luaKeywordStyle = lipgloss.NewStyle().Foreground(color.Primary).Bold(true)
+	luaStringStyle  = lipgloss.NewStyle().Foreground(color.JSONString)
+	luaNumberStyle  = lipgloss.NewStyle().Foreground(color.JSONNumber)
+	luaBuiltinStyle = lipgloss.NewStyle().Foreground(color.Warning)
+	luaCommentStyle = lipgloss.NewStyle().Foreground(color.Grey).Italic(true)
+)
+
+var luaKeywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true,
+	"false": true, "for": true, "function": true, "goto": true, "if": true, "in": true,
+	"local": true, "nil": true, "not": true, "or": true, "repeat": true, "return": true,
+	"then": true, "true": true, "until": true, "while": true,
+}
+
+// Globals of the Lua engine of Redis, e.g. redis.call and cjson.encode.
+var luaBuiltins = map[string]bool{
+	"redis": true, "KEYS": true, "ARGV": true, "cjson": true, "cmsgpack": true, "bit": true,
+	"struct": true, "string": true, "table": true, "math": true, "tonumber": true,
+	"tostring": true, "type": true, "pairs": true, "ipairs": true, "unpack": true,
+	"error": true, "pcall": true, "assert": true, "select": true,
+}
+
+// Lua colors keywords, strings, numbers, comments and the globals of Redis scripts in s,
+// which is displayed as is otherwise.
+func Lua(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s) * 2)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case strings.HasPrefix(s[i:], "--"):
+			j := strings.IndexByte(s[i:], '\n')
+			if level, ok := longBracket(s, i+2); ok {
+				j = endOfLongBracket(s, i+2, level) - i
+			} else if j < 0 {
+				j = len(s) - i
+			}
+			luaToken(&sb, luaCommentStyle, s[i:i+j])
+			i += j
+
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(s) && s[j] != c && s[j] != '\n' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(s))
+			luaToken(&sb, luaStringStyle, s[i:j])
+			i = j
+
+		case c == '[':
+			level, ok := longBracket(s, i)
+			if !ok {
+				sb.WriteByte(c)
+				i++
+				continue
+			}
+			j := endOfLongBracket(s, i, level)
+			luaToken(&sb, luaStringStyle, s[i:j])
+			i = j
+
+		case isDigit(c) || (c == '.' && i+1 < len(s) && isDigit(s[i+1])):
+			j := i + 1
+			for j < len(s) && (isIdentByte(s[j]) || s[j] == '.' ||
+				((s[j] == '-' || s[j] == '+') && (s[j-1] == 'e' || s[j-1] == 'E' || s[j-1] == 'p' || s[j-1] == 'P'))) {
+				j++
+			}
+			luaToken(&sb, luaNumberStyle, s[i:j])
+			i = j
+
+		case isIdentByte(c):
+			j := i + 1
+			for j < len(s) && isIdentByte(s[j]) {
+				j++
+			}
+			word := s[i:j]
+			switch {
+			case luaKeywords[word]:
+				luaToken(&sb, luaKeywordStyle, word)
+			case luaBuiltins[word] && (i == 0 || s[i-1] != '.'):
+				luaToken(&sb, luaBuiltinStyle, word)
+			default:
+				sb.WriteString(word)
+			}
+			i = j
+
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// luaToken writes a token line by line, as lipgloss pads multi-line text to its widest line.
+func luaToken(sb *strings.Builder, style lipgloss.Style, text string) {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		if line != "" {
+			sb.WriteString(style.Render(line))
+		}
+	}
+}
+
+// longBracket reports whether a long bracket, e.g. [[ or [==[, opens at i, and its level.
+func longBracket(s string, i int) (int, bool) {
+	if i >= len(s) || s[i] != '[' {
+		return 0, false
+	}
+	level := 0
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '=':
+			level++
+		case '[':
+			return level, true
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// endOfLongBracket returns the index right after the long bracket of the given level opening at i.
+func endOfLongBracket(s string, i, level int) int {
+	closing := "]" + strings.Repeat("=", level) + "]"
+	start := i + level + 2
+	if j := strings.Index(s[start:], closing); j >= 0 {
+		return start + j + len(closing)
+	}
+	return len(s)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
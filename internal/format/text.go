@@ -0,0 +1,42 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Printable makes s safe to display in the terminal. Control characters other than
+// newlines and tabs, and bytes that are not valid UTF-8, are shown as \xNN escapes
+// instead of being dropped.
+func Printable(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&sb, "\\x%02x", s[i])
+		case r == '\n' || r == '\t':
+			sb.WriteRune(r)
+		case r < 32 || r == 127:
+			fmt.Fprintf(&sb, "\\x%02x", r)
+		default:
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return sb.String()
+}
+
+// Truncate cuts s to at most n bytes without splitting a multi-byte character, and appends
+// ellipsis if it was cut. s is returned as is if it is not longer than n bytes.
+func Truncate(s string, n int, ellipsis string) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + ellipsis
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/aof"
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/form"
+)
+
+const (
+	timelineFormID = "timeline"
+	replayFormID   = "replay"
+)
+
+// WithAOF enables the timeline, history and replay of an append-only file,
+// whose keys are browsed through the source of the model.
+func (m Model) WithAOF(l *aof.Log) Model {
+	m.aof = l
+	return m
+}
+
+func (m Model) updateAOFKey(key string, cmds []tea.Cmd) (Model, []tea.Cmd) {
+	switch key {
+	case "H":
+		if k := m.keyList.SelectedKey(); k != "" {
+			cmds = append(cmds, command.AOFHistory(m.aof, m.src.DB(), k))
+		}
+
+	case "T":
+		f := form.New(timelineFormID, "TIMELINE",
+			form.Field{Name: "command", Label: "Command", Placeholder: "all, or e.g. HSET"},
+			form.Field{Name: "pattern", Label: "Keys", Placeholder: "all, or e.g. user:*"},
+		)
+		m.form = &f
+
+	case "O":
+		f := form.New(replayFormID, "REPLAY",
+			form.Field{Name: "offset", Label: "Up to command", Value: fmt.Sprintf("#%d", len(m.aof.Commands))},
+		)
+		m.form = &f
+	}
+	return m, cmds
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/form"
+)
+
+const bitmapFormID = "bitmap"
+
+// newBitmapForm edits or reads the selected string as a bitmap, with the values last submitted.
+func (m Model) newBitmapForm() form.Form {
+	v := m.bitmap
+	return form.New(bitmapFormID, "BITMAP "+m.keyList.SelectedKey(),
+		form.Field{Name: "action", Label: "Action", Value: v["action"], Options: command.BitmapActions},
+		form.Field{Name: "offset", Label: "Offset", Value: v["offset"], Placeholder: "the bit to toggle, or where integers start: bits, or #N for the Nth integer"},
+		form.Field{Name: "type", Label: "Type", Value: v["type"], Placeholder: "for bitfield, u8 if empty, or e.g. i16 (i1 to i64, u1 to u63)"},
+	)
+}
+
+func (m Model) runBitmap(v map[string]string) tea.Cmd {
+	key := m.keyList.SelectedKey()
+	if v["action"] == command.BitmapBitfield {
+		return command.ShowBitfield(m.ctx, m.src, key, v["type"], v["offset"])
+	}
+	return command.ToggleBit(m.ctx, m.src, m.cache, key, v["offset"], m.pageSize)
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/form"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+)
+
+const compareFormID = "compare"
+
+func (m Model) updateDiffKey(key string, cmds []tea.Cmd) (Model, []tea.Cmd) {
+	switch key {
+	case "D":
+		f := form.New(compareFormID, "COMPARE",
+			form.Field{Name: "target", Label: "Compare with", Placeholder: "this DB, a DB number, a profile or redis://host:port/db"},
+			form.Field{Name: "key", Label: "Key", Placeholder: "every key, or a key to compare the selected key with"},
+		)
+		m.form = &f
+
+	case "=":
+		if m.compare == nil {
+			cmds = append(cmds, command.NewWarningInfoCmd(infoid.New(), "Choose what to compare with first (D).", expiration))
+			return m, cmds
+		}
+		k := m.keyList.SelectedKey()
+		cmds = append(cmds, m.diffKey(k, k))
+	}
+	return m, cmds
+}
+
+// diff compares the selected key with target in the compare source, or every key if target is empty.
+func (m Model) diff(target string) tea.Cmd {
+	if target != "" {
+		return m.diffKey(m.keyList.SelectedKey(), target)
+	}
+	if m.task != nil {
+		return command.NewWarningInfoCmd(infoid.New(), m.task.Task+" is in progress, try again later.", expiration)
+	}
+	return command.Diff(m.ctx, m.src, m.compare)
+}
+
+func (m Model) diffKey(selected, target string) tea.Cmd {
+	if selected == "" {
+		return command.NewWarningInfoCmd(infoid.New(), "Select a key to compare first.", expiration)
+	}
+	return command.DiffKey(m.ctx, m.src, selected, m.compare, target, m.widthRightPane()-2)
+}
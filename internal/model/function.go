@@ -0,0 +1,44 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/form"
+	"github.com/hirotake111/redisclient/internal/dump"
+)
+
+const functionFormID = "function"
+
+// newFunctionForm opens the functions browser with the values last submitted, and the filtered
+// keys, or the selected key if no filter is applied, as KEYS.
+func (m Model) newFunctionForm() form.Form {
+	v := m.functions
+	return form.New(functionFormID, "FUNCTIONS",
+		form.Field{Name: "action", Label: "Action", Value: v["action"], Options: command.FunctionActions},
+		form.Field{Name: "name", Label: "Name", Value: v["name"], Placeholder: "a function to call, a library, or a library pattern to list"},
+		form.Field{Name: "keys", Label: "KEYS", Value: dump.InlineCommand(m.scriptKeys()), Placeholder: "key names separated by spaces"},
+		form.Field{Name: "args", Label: "ARGV", Value: v["args"], Placeholder: "arguments separated by spaces, quoted like in redis-cli"},
+		form.Field{Name: "path", Label: "File", Value: v["path"], Placeholder: "a .lua file to load, or the file to dump to or restore from"},
+		form.Field{Name: "policy", Label: "If it exists", Value: v["policy"], Options: command.FunctionPolicies},
+	)
+}
+
+func (m Model) runFunction(v map[string]string) tea.Cmd {
+	replace := v["policy"] == command.FunctionReplace
+	switch v["action"] {
+	case command.FunctionCode:
+		return command.ShowFunctionCode(m.ctx, m.src, v["name"])
+	case command.FunctionCall, command.FunctionCallRO:
+		return command.CallFunction(m.ctx, m.src, m.cache, v["name"], v["keys"], v["args"], v["action"] == command.FunctionCallRO)
+	case command.FunctionLoad:
+		return command.LoadFunctionLibrary(m.ctx, m.src, v["path"], replace)
+	case command.FunctionDelete:
+		return command.DeleteFunctionLibrary(m.ctx, m.src, v["name"])
+	case command.FunctionDump:
+		return command.DumpFunctions(m.ctx, m.src, v["path"])
+	case command.FunctionRestore:
+		return command.RestoreFunctions(m.ctx, m.src, v["path"], replace)
+	default:
+		return command.ListFunctions(m.ctx, m.src, v["name"])
+	}
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/form"
+)
+
+const geoFormID = "geo"
+
+// newGeoForm plots or searches the selected geo index with the values last submitted.
+func (m Model) newGeoForm() form.Form {
+	v := m.geo
+	return form.New(geoFormID, "GEO "+m.keyList.SelectedKey(),
+		form.Field{Name: "action", Label: "Action", Value: v["action"], Options: command.GeoActions},
+		form.Field{Name: "from", Label: "From", Value: v["from"], Placeholder: "for searches, a member or longitude,latitude, e.g. 13.361389,38.115556"},
+		form.Field{Name: "size", Label: "Size", Value: v["size"], Placeholder: "the radius, or the width and height of the box, e.g. 200 or 400 300"},
+		form.Field{Name: "unit", Label: "Unit", Value: v["unit"], Options: command.GeoUnits},
+		form.Field{Name: "count", Label: "Count", Value: v["count"], Placeholder: "the nearest members found at most, every member if empty"},
+	)
+}
+
+func (m Model) runGeo(v map[string]string) tea.Cmd {
+	// Inside the borders and title of the viewport
+	width, height := m.widthRightPane()-2, m.heightValueDisplay()-3
+	key := m.keyList.SelectedKey()
+	if v["action"] == command.GeoPlot {
+		return command.PlotGeo(m.ctx, m.src, key, width, height)
+	}
+	return command.SearchGeo(m.ctx, m.src, key, v["action"], v["from"], v["size"], v["unit"], v["count"], width, height)
+}
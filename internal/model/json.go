@@ -0,0 +1,26 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/form"
+)
+
+const jsonFormID = "json"
+
+// newJSONForm queries or edits the selected JSON document, starting from the path last used.
+func (m Model) newJSONForm() form.Form {
+	return form.New(jsonFormID, "JSON "+m.keyList.SelectedKey(),
+		form.Field{Name: "path", Label: "Path", Value: m.jsonPath, Placeholder: "a JSONPath, e.g. $.items[0].price or $..price, the root if empty"},
+		form.Field{Name: "action", Label: "Action", Options: command.JSONActions},
+		form.Field{Name: "value", Label: "Value", Placeholder: `a JSON value for set and arrappend, e.g. "text" or {"a":1}, a number for numincrby`},
+	)
+}
+
+func (m Model) runJSON(v map[string]string) tea.Cmd {
+	key := m.keyList.SelectedKey()
+	if v["action"] == command.JSONGet {
+		return command.QueryJSON(m.ctx, m.src, key, v["path"])
+	}
+	return command.EditJSON(m.ctx, m.src, m.cache, key, v["action"], v["path"], v["value"], m.pageSize)
+}
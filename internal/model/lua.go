@@ -0,0 +1,52 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/form"
+	"github.com/hirotake111/redisclient/internal/dump"
+)
+
+const luaFormID = "lua"
+
+// newLuaForm opens the workbench with the last script and arguments, and the filtered keys,
+// or the selected key if no filter is applied, as KEYS.
+func (m Model) newLuaForm() form.Form {
+	var script, args string
+	if n := len(m.luaHistory); n > 0 {
+		last := m.luaHistory[n-1]
+		script, args = last.Script, dump.InlineCommand(last.Args)
+		if last.Path != "" {
+			script = last.Path
+		}
+	}
+	return form.New(luaFormID, "LUA SCRIPT",
+		form.Field{Name: "script", Label: "Script", Value: script, Lines: 8, Placeholder: "Lua code, a .lua file, or #N to run the Nth run of the history again"},
+		form.Field{Name: "keys", Label: "KEYS", Value: dump.InlineCommand(m.scriptKeys()), Placeholder: "key names separated by spaces"},
+		form.Field{Name: "args", Label: "ARGV", Value: args, Placeholder: "arguments separated by spaces, quoted like in redis-cli"},
+		form.Field{Name: "action", Label: "Action", Options: command.LuaActions},
+	)
+}
+
+func (m Model) runLua(v map[string]string) tea.Cmd {
+	switch v["action"] {
+	case command.LuaExists:
+		return command.LuaScriptsExist(m.ctx, m.src, m.luaHistory, v["script"])
+	case command.LuaFlush:
+		return command.FlushLuaScripts(m.ctx, m.src)
+	case command.LuaHistory:
+		return command.LuaRunHistory(m.luaHistory)
+	default:
+		return command.RunLua(m.ctx, m.src, m.luaHistory, v["script"], v["keys"], v["args"], v["action"])
+	}
+}
+
+// scriptKeys returns the keys passed to scripts and functions: the filtered keys,
+// or the selected key if no filter is applied.
+func (m Model) scriptKeys() []string {
+	keys := m.keyList.FilteredKeys()
+	if keys == nil && m.keyList.SelectedKey() != "" {
+		keys = []string{m.keyList.SelectedKey()}
+	}
+	return keys
+}
@@ -6,12 +6,18 @@ import (
 	"log"
 
 	"github.com/charmbracelet/bubbles/timer"
+	"github.com/hirotake111/redisclient/internal/aof"
+	"github.com/hirotake111/redisclient/internal/cache"
+	"github.com/hirotake111/redisclient/internal/clipboard"
 	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/form"
 	"github.com/hirotake111/redisclient/internal/component/infobox"
 	"github.com/hirotake111/redisclient/internal/component/list"
 	"github.com/hirotake111/redisclient/internal/component/viewport"
+	"github.com/hirotake111/redisclient/internal/config"
+	"github.com/hirotake111/redisclient/internal/decoder"
+	"github.com/hirotake111/redisclient/internal/source"
 	"github.com/hirotake111/redisclient/internal/state"
-	"github.com/redis/go-redis/v9"
 )
 
 const (
@@ -26,8 +32,26 @@ type Model struct {
 	ctx        context.Context // Context for app
 	width      int             // Width of the terminal window
 	height     int             // Height of the terminal window
-	redis      *redis.Client   // Redis client instance
-	State      state.AppState  // Application state
+	src        source.Source   // Redis server or file the keys are browsed from
+	cache      *cache.ValueCache
+	pageSize   int64 // Number of collection elements fetched at a time
+	clipboard  clipboard.Backend
+	exportDir  string                   // Directory export files are written to
+	task       *command.TaskProgressMsg // Progress of the running background task, if any
+	form       *form.Form               // Form displayed in place of the viewport, if any
+	aof        *aof.Log                 // Append-only file being inspected, if any
+	compare    source.Source            // Source the keys are compared with, if any
+	syncKeys   []string                 // Keys to copy once the sync form is submitted, nil for every key
+	luaHistory []command.LuaRun         // Runs of the Lua script workbench, the latest last
+	functions  map[string]string        // Values last submitted in the functions form
+	modules    map[string]bool          // Modules loaded in the server, see command.GetModules
+	jsonPath   string                   // JSONPath last queried or edited
+	searches   []command.Search         // Searches run on the search screen, the latest last
+	search     *command.SearchedMsg     // Page of results of the last search, if any
+	chart      map[string]string        // Values last submitted in the time series chart form
+	bitmap     map[string]string        // Values last submitted in the bitmap form
+	geo        map[string]string        // Values last submitted in the geo form
+	State      state.AppState           // Application state
 	errorMsg   string
 	tabs       int
 	currentTab int // Also an index for Redis database
@@ -37,31 +61,38 @@ type Model struct {
 	timer      timer.Model // Timer for handling timed events
 }
 
-func NewModel(ctx context.Context, redis *redis.Client) Model {
+func NewModel(ctx context.Context, src source.Source, c *cache.ValueCache, decoders *decoder.Pipeline, cb clipboard.Backend, cfg *config.Config) Model {
 	return Model{
 		ctx:        ctx,
-		redis:      redis,
+		src:        src,
+		cache:      c,
+		pageSize:   cfg.PageSize,
+		clipboard:  cb,
+		exportDir:  cfg.ExportDir,
 		width:      80,             // Default width
 		height:     24,             // Default height
 		errorMsg:   "",             // ErrorMsg
 		tabs:       defaultTabSize, // Tabs
 		currentTab: 0,              // CurrentTab
-		keyList:    list.New([]string{}, defaultKeyListWIdth, defaultKeyListHeight),
-		viewport:   viewport.New(defaultViewportWidth, defaultViewportHeight),
+		keyList:    list.New([]string{}, defaultKeyListWIdth, defaultKeyListHeight, c, cfg.PageSize),
+		viewport:   viewport.New(defaultViewportWidth, defaultViewportHeight, decoders, cfg.MaxElements),
 		infoBox:    infobox.New(),
 		State:      state.NewAppState(),
 	}
 }
 
 func (m Model) HostName() string {
-	return m.redis.Options().Addr
+	return m.src.Name()
 }
 
 func (m Model) DB() string {
-	return fmt.Sprintf("%d", m.redis.Options().DB)
+	return fmt.Sprintf("%d", m.src.DB())
 }
 func (m Model) ConnectionString() string {
-	return fmt.Sprintf("redis://%s/%d", m.HostName(), m.redis.Options().DB)
+	if m.src.Client() == nil {
+		return fmt.Sprintf("%s/%d", m.HostName(), m.src.DB())
+	}
+	return fmt.Sprintf("redis://%s/%d", m.HostName(), m.src.DB())
 }
 
 func (m Model) UpdateWindowSize(height, width int) Model {
@@ -84,9 +115,9 @@ func (m Model) PreviousTab() Model {
 	return m
 }
 
-func (m Model) UpdateRedisClient(msg command.NewRedisClientMsg) Model {
-	m.redis = msg.Redis
-	log.Printf("Updating Redis client to %s", m.ConnectionString())
+func (m Model) UpdateSource(msg command.NewSourceMsg) Model {
+	m.src = msg.Source
+	log.Printf("Updating source to %s", m.ConnectionString())
 	return m
 }
 
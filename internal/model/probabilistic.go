@@ -0,0 +1,13 @@
+package model
+
+import (
+	"github.com/hirotake111/redisclient/internal/component/form"
+)
+
+const checkFormID = "check"
+
+func newCheckForm(key string) form.Form {
+	return form.New(checkFormID, "CHECK "+key,
+		form.Field{Name: "items", Label: "Items", Placeholder: "items separated by spaces, quoted like in redis-cli"},
+	)
+}
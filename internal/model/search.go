@@ -0,0 +1,80 @@
+package model
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/form"
+	"github.com/hirotake111/redisclient/internal/domain/infoid"
+)
+
+const searchFormID = "search"
+
+// newSearchForm opens the search screen with the last search.
+func (m Model) newSearchForm() form.Form {
+	var last command.Search
+	if n := len(m.searches); n > 0 {
+		last = m.searches[n-1]
+	}
+	return form.New(searchFormID, "SEARCH",
+		form.Field{Name: "index", Label: "Index", Value: last.Index, Placeholder: "name of the index, see the indexes action"},
+		form.Field{Name: "query", Label: "Query", Value: last.Query, Placeholder: "e.g. @title:shoes @price:[10 50], every document if empty, or #N to run the Nth query of the history again"},
+		form.Field{Name: "options", Label: "Options", Value: last.Options, Placeholder: "e.g. SORTBY price DESC, or GROUPBY 1 @brand REDUCE COUNT 0 AS n"},
+		form.Field{Name: "action", Label: "Action", Value: last.Mode, Options: command.SearchActions},
+	)
+}
+
+func (m Model) runSearch(v map[string]string) (Model, tea.Cmd) {
+	switch v["action"] {
+	case command.SearchIndexes:
+		return m, command.ListSearchIndexes(m.ctx, m.src)
+	case command.SearchInfo:
+		return m, command.SearchIndexInfo(m.ctx, m.src, v["index"])
+	case command.SearchExplain:
+		return m, command.ExplainSearch(m.ctx, m.src, v["index"], v["query"])
+	case command.SearchHistory:
+		return m, command.SearchQueryHistory(m.searches)
+	}
+
+	s, err := command.ResolveSearch(m.searches, command.Search{
+		Index:   v["index"],
+		Query:   v["query"],
+		Options: v["options"],
+		Mode:    v["action"],
+	})
+	if err != nil {
+		return m, command.NewErrorInfoCmd(infoid.New(), err, expiration)
+	}
+	m.searches = append(m.searches, s)
+	return m, command.RunSearch(m.ctx, m.src, s)
+}
+
+// updateSearchPage displays the next page of the last search with ], and the previous page with [.
+func (m Model) updateSearchPage(key string, cmds []tea.Cmd) (Model, []tea.Cmd) {
+	if m.search == nil {
+		cmds = append(cmds, command.NewWarningInfoCmd(infoid.New(), "Run a search first (Q).", expiration))
+		return m, cmds
+	}
+	s := m.search.Search
+	if key == "]" {
+		s.Offset += command.SearchPageSize
+		if int64(s.Offset) >= m.search.Total {
+			cmds = append(cmds, command.NewWarningInfoCmd(infoid.New(), "This is the last page.", expiration))
+			return m, cmds
+		}
+	} else {
+		if s.Offset == 0 {
+			cmds = append(cmds, command.NewWarningInfoCmd(infoid.New(), "This is the first page.", expiration))
+			return m, cmds
+		}
+		s.Offset = max(0, s.Offset-command.SearchPageSize)
+	}
+	cmds = append(cmds, command.RunSearch(m.ctx, m.src, s))
+	return m, cmds
+}
+
+// searchTitle is the title of the key list while it shows the documents found by a search.
+func searchTitle(msg command.SearchedMsg) string {
+	return fmt.Sprintf("HITS %d-%d/%d", msg.Search.Offset+1, msg.Search.Offset+len(msg.Keys), msg.Total)
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"github.com/hirotake111/redisclient/internal/component/form"
+	"github.com/hirotake111/redisclient/internal/dump"
+)
+
+const syncFormID = "sync"
+
+func newSyncForm(keys []string) form.Form {
+	title := "SYNC DATABASE"
+	if keys != nil {
+		title = "SYNC FILTERED KEYS"
+	}
+	return form.New(syncFormID, title,
+		form.Field{Name: "target", Label: "Copy to", Placeholder: "a DB number, a profile or redis://host:port/db"},
+		form.Field{Name: "policy", Label: "On conflict", Options: dump.SyncPolicies},
+		form.Field{Name: "method", Label: "Method", Options: dump.Methods},
+	)
+}
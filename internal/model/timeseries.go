@@ -0,0 +1,26 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/form"
+)
+
+const chartFormID = "chart"
+
+// newChartForm draws the selected time series with the values last submitted.
+func (m Model) newChartForm() form.Form {
+	v := m.chart
+	return form.New(chartFormID, "CHART "+m.keyList.SelectedKey(),
+		form.Field{Name: "window", Label: "Time window", Value: v["window"], Placeholder: "up to the latest sample, e.g. 15m, 24h or 7d, the whole series if empty"},
+		form.Field{Name: "bucket", Label: "Bucket size", Value: v["bucket"], Placeholder: "e.g. 1m or 1h, fitted to the chart width if empty"},
+		form.Field{Name: "aggregation", Label: "Aggregation", Value: v["aggregation"], Options: command.Aggregations},
+		form.Field{Name: "filter", Label: "Label filter", Value: v["filter"], Placeholder: "to overlay the series matching labels, e.g. sensor=temp area=(north,south)"},
+	)
+}
+
+func (m Model) drawChart(v map[string]string) tea.Cmd {
+	// Inside the borders and title of the viewport
+	width, height := m.widthRightPane()-2, m.heightValueDisplay()-3
+	return command.ChartTimeSeries(m.ctx, m.src, m.keyList.SelectedKey(), v["window"], v["bucket"], v["aggregation"], v["filter"], width, height)
+}
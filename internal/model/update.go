@@ -1,12 +1,16 @@
 package model
 
 import (
+	"fmt"
 	"log"
+	"path/filepath"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/hirotake111/redisclient/internal/command"
+	"github.com/hirotake111/redisclient/internal/component/form"
 	"github.com/hirotake111/redisclient/internal/domain/infoid"
+	"github.com/hirotake111/redisclient/internal/dump"
 	"github.com/hirotake111/redisclient/internal/util"
 )
 
@@ -23,9 +27,14 @@ func doTick() tea.Cmd {
 
 func (m Model) Init() tea.Cmd {
 	log.Print("Initializing model...")
+	txt := "Connected to Redis successfully."
+	if m.src.Client() == nil {
+		txt = fmt.Sprintf("Opened %s (read-only).", m.src.Name())
+	}
 	return tea.Batch(
-		command.GetKeys(m.ctx, m.redis, ""),
-		command.NewInfoInfoCmd(infoid.New(), "Connected to Redis successfully.", expiration),
+		command.GetKeys(m.ctx, m.src, ""),
+		command.GetModules(m.ctx, m.src),
+		command.NewInfoInfoCmd(infoid.New(), txt, expiration),
 		doTick(),
 	)
 }
@@ -36,6 +45,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	util.LogMsg("Update()", msg)
 
+	// An open form takes all key presses
+	if msg, ok := msg.(tea.KeyMsg); ok && m.form != nil {
+		f, cmd := m.form.Update(msg)
+		m.form = &f
+		return m, cmd
+	}
+
 	// Update app state
 	m.State, cmd = m.State.Update(msg)
 	cmds = append(cmds, cmd)
@@ -49,7 +65,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	cmds = append(cmds, cmd)
 
 	// Update key list
-	m.keyList, cmd = m.keyList.Update(m.ctx, m.redis, msg, m.State)
+	m.keyList, cmd = m.keyList.Update(m.ctx, m.src, msg, m.State)
 	cmds = append(cmds, cmd)
 	for _, c := range cmds {
 		if c != nil {
@@ -71,20 +87,139 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case command.TickMsg:
 		log.Print("Received tick message")
 		cmds = append(cmds, doTick())
-		if m.keyList.IsBeingUnfiltered() {
-			cmds = append(cmds, command.GetKeys(m.ctx, m.redis, ""))
+		if m.keyList.IsBeingUnfiltered() && !m.keyList.ShowingHits() {
+			cmds = append(cmds, command.PollKeys(m.ctx, m.src))
+		}
+		return m, tea.Batch(cmds...)
+
+	case command.NewSourceMsg:
+		log.Print("Received new source message")
+		if msg.Source.Name() != m.src.Name() {
+			// Cached values belong to another data set, e.g. another replay of an AOF
+			m.cache.Purge()
+			cmds = append(cmds, command.GetModules(m.ctx, msg.Source))
+		}
+		m = m.UpdateSource(msg)
+		cmds = append(cmds, command.GetKeys(m.ctx, m.src, "")) // Re-fetch keys with the new source
+		return m, tea.Batch(cmds...)
+
+	case command.SearchedMsg:
+		m.search = &msg
+		if len(msg.Keys) > 0 {
+			m.keyList = m.keyList.ShowHits(msg.Keys, searchTitle(msg))
+		}
+		return m, tea.Batch(cmds...)
+
+	case command.ModulesLoadedMsg:
+		m.modules = msg.Modules
+		return m, tea.Batch(cmds...)
+
+	case command.TaskProgressMsg:
+		m.task = &msg
+		cmds = append(cmds, msg.Next)
+		return m, tea.Batch(cmds...)
+
+	case command.TaskFinishedMsg:
+		m.task = nil
+		cmds = append(cmds, func() tea.Msg { return msg.Result })
+		return m, tea.Batch(cmds...)
+
+	case form.SubmittedMsg:
+		m.form = nil
+		v := msg.Values
+		switch msg.ID {
+		case importFormID:
+			cmds = append(cmds, command.Import(m.ctx, m.src, m.cache, v["path"], dump.Policy(v["policy"]), v["mode"] == importModeDryRun))
+		case timelineFormID:
+			cmds = append(cmds, command.AOFTimeline(m.aof, m.src.DB(), v["command"], v["pattern"]))
+		case syncFormID:
+			cmds = append(cmds, command.Sync(m.ctx, m.src, m.cache, m.syncKeys, v["target"], dump.Policy(v["policy"]), v["method"]))
+		case luaFormID:
+			cmds = append(cmds, m.runLua(v))
+		case searchFormID:
+			var cmd tea.Cmd
+			m, cmd = m.runSearch(v)
+			cmds = append(cmds, cmd)
+		case chartFormID:
+			m.chart = v
+			cmds = append(cmds, m.drawChart(v))
+		case bitmapFormID:
+			m.bitmap = v
+			cmds = append(cmds, m.runBitmap(v))
+		case geoFormID:
+			m.geo = v
+			cmds = append(cmds, m.runGeo(v))
+		case checkFormID:
+			cmds = append(cmds, command.CheckItems(m.ctx, m.src, m.keyList.SelectedKey(), v["items"]))
+		case jsonFormID:
+			m.jsonPath = v["path"]
+			cmds = append(cmds, m.runJSON(v))
+		case functionFormID:
+			m.functions = v
+			cmds = append(cmds, m.runFunction(v))
+		case compareFormID:
+			cmds = append(cmds, command.OpenCompareTarget(m.ctx, m.src, v["target"], v["key"]))
+		case replayFormID:
+			n, err := command.ParseAOFOffset(v["offset"])
+			if err != nil {
+				cmds = append(cmds, command.NewErrorInfoCmd(infoid.New(), err, expiration))
+				break
+			}
+			cmds = append(cmds, command.ReplayAOF(m.ctx, m.aof, n, m.src.DB()))
+		}
+		return m, tea.Batch(cmds...)
+
+	case form.CancelledMsg:
+		m.form = nil
+		return m, tea.Batch(cmds...)
+
+	case command.CompareTargetMsg:
+		if m.compare != msg.Source && m.task == nil {
+			// Unless a comparison may still be reading it
+			command.CloseTarget(m.src, m.compare)
+		}
+		m.compare = msg.Source
+		cmds = append(cmds, m.diff(msg.Key))
+		return m, tea.Batch(cmds...)
+
+	case command.ExportRequestedMsg:
+		if m.task != nil {
+			cmds = append(cmds, command.NewWarningInfoCmd(infoid.New(), m.task.Task+" is in progress, try again later.", expiration))
+			return m, tea.Batch(cmds...)
 		}
+		name := fmt.Sprintf("red-db%d-%s.%s", m.src.DB(), time.Now().Format("20060102-150405"), msg.Format)
+		path := filepath.Join(m.exportDir, name)
+		cmds = append(cmds, command.Export(m.ctx, m.src, msg.Keys, path, msg.Format))
+		return m, tea.Batch(cmds...)
+
+	case command.SyncRequestedMsg:
+		if m.task != nil {
+			cmds = append(cmds, command.NewWarningInfoCmd(infoid.New(), m.task.Task+" is in progress, try again later.", expiration))
+			return m, tea.Batch(cmds...)
+		}
+		m.syncKeys = msg.Keys
+		f := newSyncForm(msg.Keys)
+		m.form = &f
+		return m, tea.Batch(cmds...)
+
+	case command.LuaRanMsg:
+		m.luaHistory = append(m.luaHistory, msg.Run)
+		// Scripts may have changed any key
+		m.cache.Purge()
+		cmds = append(cmds, command.GetKeys(m.ctx, m.src, ""))
+		return m, tea.Batch(cmds...)
+
+	case command.CopyRequestedMsg:
+		cmds = append(cmds, command.CopyValueToClipboard(m.ctx, m.clipboard, msg.Value))
 		return m, tea.Batch(cmds...)
 
-	case command.NewRedisClientMsg:
-		log.Print("Received new Redis client message")
-		m = m.UpdateRedisClient(msg)
-		cmds = append(cmds, command.GetKeys(m.ctx, m.redis, "")) // Re-fetch keys with the new client
+	case command.ValuePageRequestedMsg:
+		cmds = append(cmds, command.GetValuePage(m.ctx, m.src, msg.Key, msg.Type, msg.Cursor, m.pageSize))
 		return m, tea.Batch(cmds...)
 
 	case command.HighlightedKeyUpdatedMsg:
 		log.Printf("Highlighted key updated to: %s", msg.Key)
-		return m, command.GetValue(m.ctx, m.redis, msg.Key)
+		return m, command.GetValue(m.ctx, m.src, m.cache, msg.Key, m.pageSize)
 	}
 
 	return m, tea.Batch(cmds...)
@@ -100,14 +235,143 @@ func (m Model) updateWithKey(key string) (Model, []tea.Cmd) {
 
 	case tea.KeyTab.String():
 		m = m.NextTab()
-		cmds = append(cmds, command.SwitchTab(m.ctx, m.redis, m.currentTab))
+		cmds = append(cmds, command.SwitchTab(m.ctx, m.src, m.currentTab))
+		return m, cmds
+
+	case "i":
+		if m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		if m.task != nil {
+			cmds = append(cmds, command.NewWarningInfoCmd(infoid.New(), m.task.Task+" is in progress, try again later.", expiration))
+			return m, cmds
+		}
+		f := newImportForm()
+		m.form = &f
 		return m, cmds
 
 	case tea.KeyShiftTab.String():
 		m = m.PreviousTab()
-		cmds = append(cmds, command.SwitchTab(m.ctx, m.redis, m.currentTab))
+		cmds = append(cmds, command.SwitchTab(m.ctx, m.src, m.currentTab))
+		return m, cmds
+
+	case "L":
+		if m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		f := m.newLuaForm()
+		m.form = &f
 		return m, cmds
+
+	case "Q", "]", "[":
+		if m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		if !m.modules[command.ModuleSearch] {
+			cmds = append(cmds, command.NewWarningInfoCmd(infoid.New(), "RediSearch is not loaded on "+m.src.Name()+".", expiration))
+			return m, cmds
+		}
+		if key != "Q" {
+			return m.updateSearchPage(key, cmds)
+		}
+		f := m.newSearchForm()
+		m.form = &f
+		return m, cmds
+
+	case "G":
+		if m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		if !m.modules[command.ModuleTimeSeries] {
+			cmds = append(cmds, command.NewWarningInfoCmd(infoid.New(), "RedisTimeSeries is not loaded on "+m.src.Name()+".", expiration))
+			return m, cmds
+		}
+		f := m.newChartForm()
+		m.form = &f
+		return m, cmds
+
+	case "B":
+		if m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		f := m.newBitmapForm()
+		m.form = &f
+		return m, cmds
+
+	case "P":
+		if m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		f := m.newGeoForm()
+		m.form = &f
+		return m, cmds
+
+	case "M":
+		if m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		if !m.modules[command.ModuleBloom] {
+			cmds = append(cmds, command.NewWarningInfoCmd(infoid.New(), "RedisBloom is not loaded on "+m.src.Name()+".", expiration))
+			return m, cmds
+		}
+		f := newCheckForm(m.keyList.SelectedKey())
+		m.form = &f
+		return m, cmds
+
+	case "J":
+		if m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		if !m.modules[command.ModuleJSON] {
+			cmds = append(cmds, command.NewWarningInfoCmd(infoid.New(), "RedisJSON is not loaded on "+m.src.Name()+".", expiration))
+			return m, cmds
+		}
+		f := m.newJSONForm()
+		m.form = &f
+		return m, cmds
+
+	case "F":
+		if m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		f := m.newFunctionForm()
+		m.form = &f
+		return m, cmds
+
+	case "C":
+		if m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		if m.task != nil && m.task.Cancel != nil {
+			m.task.Cancel()
+		}
+		return m, cmds
+
+	case "D", "=":
+		if m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		return m.updateDiffKey(key, cmds)
+
+	case "H", "T", "O":
+		if m.aof == nil || m.keyList.IsFitering() || !m.State.ListActive() {
+			return m, cmds
+		}
+		return m.updateAOFKey(key, cmds)
 	}
 
 	return m, cmds
 }
+
+const (
+	importFormID     = "import"
+	importModeDryRun = "dry run"
+)
+
+func newImportForm() form.Form {
+	return form.New(importFormID, "IMPORT",
+		form.Field{Name: "path", Label: "File", Placeholder: "dump.json"},
+		form.Field{Name: "policy", Label: "On conflict", Options: dump.Policies},
+		form.Field{Name: "mode", Label: "Mode", Options: []string{importModeDryRun, "import"}},
+	)
+}
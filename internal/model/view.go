@@ -18,14 +18,25 @@ var (
 	helpBoxStyle  = lipgloss.NewStyle().PaddingTop(helpBoxPaddingTop)
 )
 
-func (m Model) View() string {
+// widthRightPane returns the width of the viewport and info box.
+func (m Model) widthRightPane() int {
 	width := m.width - appShellPadding*2
+	return width - width/3 - 5
+}
+
+// heightValueDisplay returns the height of the viewport.
+func (m Model) heightValueDisplay() int {
 	height := m.height - appShellPadding*2 - helpBoxHeight - helpBoxPaddingTop
+	return height - heightErrorBox - 7
+}
+
+func (m Model) View() string {
+	width := m.width - appShellPadding*2
 
-	heightValueDisplay := height - heightErrorBox - 7
+	heightValueDisplay := m.heightValueDisplay()
 	heightLeftPane := heightValueDisplay + heightErrorBox + 2
 	widthLeftPane := width / 3
-	widthRightPane := width - widthLeftPane - 5
+	widthRightPane := m.widthRightPane()
 
 	// Help box
 	helpBox := helpBoxStyle.Render(helpbox.New(helpBoxHeight))
@@ -42,9 +53,15 @@ func (m Model) View() string {
 
 	// Viewport
 	viewport := m.viewport.View(widthRightPane, heightValueDisplay, m.State)
+	if m.form != nil {
+		viewport = m.form.View(widthRightPane, heightValueDisplay)
+	}
 
 	// Connection display
 	bottom := component.HostHeader(m.HostName())
+	if m.task != nil {
+		bottom = lipgloss.JoinHorizontal(lipgloss.Bottom, bottom, component.TaskProgress(m.task.Task, m.task.Done, m.task.Total))
+	}
 
 	// Right pane
 	right := lipgloss.JoinVertical(lipgloss.Top, viewport, infoBox)
@@ -0,0 +1,285 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var errTruncated = errors.New("truncated encoded value")
+
+// ziplist returns the items of a ziplist, the compact encoding of small values before Redis 7.
+func ziplist(b []byte) ([]string, error) {
+	const headerSize = 10 // Total bytes, offset of the tail and number of items
+	if len(b) < headerSize+1 {
+		return nil, errTruncated
+	}
+	var items []string
+	i := headerSize
+	for {
+		if i >= len(b) {
+			return nil, errTruncated
+		}
+		if b[i] == 0xFF {
+			return items, nil
+		}
+		// Length of the previous entry
+		if b[i] == 0xFE {
+			i += 5
+		} else {
+			i++
+		}
+		if i >= len(b) {
+			return nil, errTruncated
+		}
+
+		enc := b[i]
+		var item string
+		var size int
+		switch {
+		case enc>>6 == 0:
+			n := int(enc & 0x3F)
+			item, size = bytesAt(b, i+1, n, 1+n)
+		case enc>>6 == 1:
+			if i+1 >= len(b) {
+				return nil, errTruncated
+			}
+			n := int(enc&0x3F)<<8 | int(b[i+1])
+			item, size = bytesAt(b, i+2, n, 2+n)
+		case enc == 0x80:
+			if i+5 > len(b) {
+				return nil, errTruncated
+			}
+			n := int(binary.BigEndian.Uint32(b[i+1:]))
+			item, size = bytesAt(b, i+5, n, 5+n)
+		case enc == 0xC0:
+			item, size = intAt(b, i+1, 2), 3
+		case enc == 0xD0:
+			item, size = intAt(b, i+1, 4), 5
+		case enc == 0xE0:
+			item, size = intAt(b, i+1, 8), 9
+		case enc == 0xF0:
+			item, size = intAt(b, i+1, 3), 4
+		case enc == 0xFE:
+			item, size = intAt(b, i+1, 1), 2
+		case enc >= 0xF1 && enc <= 0xFD:
+			item, size = strconv.Itoa(int(enc&0x0F)-1), 1
+		default:
+			return nil, fmt.Errorf("invalid ziplist encoding 0x%02x", enc)
+		}
+		if size < 0 || i+size > len(b) {
+			return nil, errTruncated
+		}
+		items = append(items, item)
+		i += size
+	}
+}
+
+// listpack returns the items of a listpack, the compact encoding of small values since Redis 7.
+func listpack(b []byte) ([]string, error) {
+	const headerSize = 6 // Total bytes and number of items
+	if len(b) < headerSize+1 {
+		return nil, errTruncated
+	}
+	var items []string
+	i := headerSize
+	for {
+		if i >= len(b) {
+			return nil, errTruncated
+		}
+		enc := b[i]
+		if enc == 0xFF {
+			return items, nil
+		}
+
+		var item string
+		var size int // Size of the encoding and data, without the back length
+		switch {
+		case enc&0x80 == 0:
+			item, size = strconv.Itoa(int(enc)), 1
+		case enc&0xC0 == 0x80:
+			n := int(enc & 0x3F)
+			item, size = bytesAt(b, i+1, n, 1+n)
+		case enc&0xE0 == 0xC0:
+			if i+1 >= len(b) {
+				return nil, errTruncated
+			}
+			v := int(enc&0x1F)<<8 | int(b[i+1])
+			if v >= 1<<12 {
+				v -= 1 << 13
+			}
+			item, size = strconv.Itoa(v), 2
+		case enc&0xF0 == 0xE0:
+			if i+1 >= len(b) {
+				return nil, errTruncated
+			}
+			n := int(enc&0x0F)<<8 | int(b[i+1])
+			item, size = bytesAt(b, i+2, n, 2+n)
+		case enc == 0xF0:
+			if i+5 > len(b) {
+				return nil, errTruncated
+			}
+			n := int(binary.LittleEndian.Uint32(b[i+1:]))
+			item, size = bytesAt(b, i+5, n, 5+n)
+		case enc == 0xF1:
+			item, size = intAt(b, i+1, 2), 3
+		case enc == 0xF2:
+			item, size = intAt(b, i+1, 3), 4
+		case enc == 0xF3:
+			item, size = intAt(b, i+1, 4), 5
+		case enc == 0xF4:
+			item, size = intAt(b, i+1, 8), 9
+		default:
+			return nil, fmt.Errorf("invalid listpack encoding 0x%02x", enc)
+		}
+		if size < 0 {
+			return nil, errTruncated
+		}
+		i += size + backlenSize(size)
+		if i > len(b) {
+			return nil, errTruncated
+		}
+		items = append(items, item)
+	}
+}
+
+// backlenSize returns the number of bytes used to store the length of a listpack entry.
+func backlenSize(n int) int {
+	switch {
+	case n <= 127:
+		return 1
+	case n < 16383:
+		return 2
+	case n < 2097151:
+		return 3
+	case n < 268435455:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// intset returns the members of an intset, the encoding of small sets of integers.
+func intset(b []byte) ([]string, error) {
+	if len(b) < 8 {
+		return nil, errTruncated
+	}
+	width := int(binary.LittleEndian.Uint32(b))
+	n := int(binary.LittleEndian.Uint32(b[4:]))
+	if width != 2 && width != 4 && width != 8 {
+		return nil, fmt.Errorf("invalid intset encoding %d", width)
+	}
+	if len(b) < 8+n*width {
+		return nil, errTruncated
+	}
+	items := make([]string, 0, n)
+	for i := range n {
+		items = append(items, intAt(b, 8+i*width, width))
+	}
+	return items, nil
+}
+
+// zipmap returns the fields and values of a zipmap, the encoding of small hashes before Redis 2.6.
+func zipmap(b []byte) ([]string, error) {
+	var items []string
+	i := 1 // Number of entries, unreliable above 253
+	for {
+		if i >= len(b) {
+			return nil, errTruncated
+		}
+		if b[i] == 0xFF {
+			if len(items)%2 != 0 {
+				return nil, errTruncated
+			}
+			return items, nil
+		}
+		n := int(b[i])
+		i++
+		if n == 254 {
+			if i+4 > len(b) {
+				return nil, errTruncated
+			}
+			n = int(binary.LittleEndian.Uint32(b[i:]))
+			i += 4
+		}
+		// Values are followed by a number of free bytes
+		free := 0
+		if len(items)%2 == 1 {
+			if i >= len(b) {
+				return nil, errTruncated
+			}
+			free = int(b[i])
+			i++
+		}
+		if i+n+free > len(b) {
+			return nil, errTruncated
+		}
+		items = append(items, string(b[i:i+n]))
+		i += n + free
+	}
+}
+
+// bytesAt returns n bytes at offset i as a string and size, or a negative size if b is too short.
+func bytesAt(b []byte, i, n, size int) (string, int) {
+	if i+n > len(b) {
+		return "", -1
+	}
+	return string(b[i : i+n]), size
+}
+
+// intAt formats the little-endian signed integer of the given width at offset i.
+func intAt(b []byte, i, width int) string {
+	if i+width > len(b) {
+		return ""
+	}
+	var v uint64
+	for j := width - 1; j >= 0; j-- {
+		v = v<<8 | uint64(b[i+j])
+	}
+	shift := 64 - 8*width
+	return strconv.FormatInt(int64(v<<shift)>>shift, 10)
+}
+
+// lzfDecompress decompresses data compressed with LZF, which Redis uses for large strings.
+func lzfDecompress(in []byte, outLen int) ([]byte, error) {
+	out := make([]byte, 0, outLen)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+		if ctrl < 32 { // Literal run of ctrl+1 bytes
+			n := ctrl + 1
+			if i+n > len(in) {
+				return nil, errTruncated
+			}
+			out = append(out, in[i:i+n]...)
+			i += n
+			continue
+		}
+
+		// Back reference
+		n := ctrl >> 5
+		if n == 7 {
+			if i >= len(in) {
+				return nil, errTruncated
+			}
+			n += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, errTruncated
+		}
+		ref := len(out) - (ctrl&0x1F)<<8 - int(in[i]) - 1
+		i++
+		if ref < 0 {
+			return nil, errors.New("invalid LZF back reference")
+		}
+		for j := range n + 2 {
+			out = append(out, out[ref+j])
+		}
+	}
+	if len(out) != outLen {
+		return nil, fmt.Errorf("LZF decompressed %d bytes, expected %d", len(out), outLen)
+	}
+	return out, nil
+}
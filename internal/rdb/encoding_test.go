@@ -0,0 +1,118 @@
+package rdb
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEncodings(t *testing.T) {
+	const (
+		ziplistHeader  = "\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"
+		listpackHeader = "\x00\x00\x00\x00\x00\x00"
+	)
+	tests := []struct {
+		name   string
+		decode func([]byte) ([]string, error)
+		in     string
+		want   []string
+		isErr  bool
+	}{
+		{
+			name:   "ziplist",
+			decode: ziplist,
+			// A 1-byte string, an int16, an immediate integer and a 24-bit integer
+			in:   ziplistHeader + "\x00\x01a" + "\x03\xC0\x2C\x01" + "\x04\xF3" + "\x02\xF0\xFF\xFF\xFF" + "\xFF",
+			want: []string{"a", "300", "2", "-1"},
+		},
+		{name: "empty ziplist", decode: ziplist, in: ziplistHeader + "\xFF", want: nil},
+		{name: "ziplist without end", decode: ziplist, in: ziplistHeader + "\x00\x01a", isErr: true},
+		{name: "ziplist string past the end", decode: ziplist, in: ziplistHeader + "\x00\x05ab\xFF", isErr: true},
+		{name: "ziplist truncated integer", decode: ziplist, in: ziplistHeader + "\x00\xE0\x01\x02", isErr: true},
+		{name: "ziplist oversized string", decode: ziplist, in: ziplistHeader + "\x00\x80\xFF\xFF\xFF\xFFa\xFF", isErr: true},
+		{name: "ziplist invalid encoding", decode: ziplist, in: ziplistHeader + "\x00\xC1\xFF", isErr: true},
+		{name: "ziplist too short", decode: ziplist, in: "\x00\x00", isErr: true},
+		{
+			name:   "listpack",
+			decode: listpack,
+			// A 7-bit integer, a 6-bit string, a 13-bit negative integer and an int16
+			in:   listpackHeader + "\x05\x01" + "\x81a\x02" + "\xDF\xFF\x02" + "\xF1\xE8\x03\x03" + "\xFF",
+			want: []string{"5", "a", "-1", "1000"},
+		},
+		{name: "listpack without end", decode: listpack, in: listpackHeader + "\x05\x01", isErr: true},
+		{name: "listpack string past the end", decode: listpack, in: listpackHeader + "\x85ab\xFF", isErr: true},
+		{name: "listpack oversized string", decode: listpack, in: listpackHeader + "\xF0\xFF\xFF\xFF\xFFa\xFF", isErr: true},
+		{name: "listpack invalid encoding", decode: listpack, in: listpackHeader + "\xF5\x01\xFF", isErr: true},
+		{
+			name:   "intset",
+			decode: intset,
+			in:     "\x04\x00\x00\x00\x02\x00\x00\x00" + "\x01\x00\x00\x00" + "\xFE\xFF\xFF\xFF",
+			want:   []string{"1", "-2"},
+		},
+		{name: "intset invalid width", decode: intset, in: "\x03\x00\x00\x00\x01\x00\x00\x00\x01\x00\x00", isErr: true},
+		{name: "intset truncated", decode: intset, in: "\x08\x00\x00\x00\x02\x00\x00\x00\x01", isErr: true},
+		{name: "intset oversized", decode: intset, in: "\x08\x00\x00\x00\xFF\xFF\xFF\xFF", isErr: true},
+		{name: "intset too short", decode: intset, in: "\x02\x00", isErr: true},
+		{
+			name:   "zipmap",
+			decode: zipmap,
+			// A field, then a value followed by a free byte
+			in:   "\x01" + "\x01f" + "\x01\x01vx" + "\xFF",
+			want: []string{"f", "v"},
+		},
+		{name: "zipmap field without value", decode: zipmap, in: "\x01\x01f\xFF", isErr: true},
+		{name: "zipmap past the end", decode: zipmap, in: "\x01\x05f", isErr: true},
+		{name: "zipmap oversized", decode: zipmap, in: "\x01\xFE\xFF\xFF\xFF\xFFf\xFF", isErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.decode([]byte(tt.in))
+			if tt.isErr {
+				if err == nil {
+					t.Fatalf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLZFDecompress(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		outLen int
+		want   string
+		isErr  bool
+	}{
+		{name: "literal", in: "\x02abc", outLen: 3, want: "abc"},
+		{name: "back reference", in: "\x01ab\x20\x01", outLen: 5, want: "ababa"},
+		{name: "long back reference", in: "\x00a\xE0\x00\x00", outLen: 10, want: "aaaaaaaaaa"},
+		{name: "truncated literal", in: "\x05ab", outLen: 6, isErr: true},
+		{name: "truncated back reference", in: "\x00a\x20", outLen: 4, isErr: true},
+		{name: "back reference before the start", in: "\x00a\x20\x05", outLen: 4, isErr: true},
+		{name: "length mismatch", in: "\x02abc", outLen: 4, isErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lzfDecompress([]byte(tt.in), tt.outLen)
+			if tt.isErr {
+				if err == nil {
+					t.Fatalf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,810 @@
+// Package rdb parses Redis RDB files into memory, so that they can be browsed without a server.
+package rdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hirotake111/redisclient/internal/values"
+)
+
+const (
+	minVersion = 1
+	maxVersion = 12
+	// maxStringLength bounds the lengths of strings read from the file, so that a corrupted
+	// length fails instead of exhausting memory.
+	maxStringLength = 1 << 32
+	// readChunk is the size above which strings are read in chunks rather than allocated
+	// up front, so that lengths past the end of the file fail after reading it.
+	readChunk = 1 << 20
+	// lzfMaxRatio is the largest expansion of LZF, 264 bytes from a 3-byte back reference.
+	lzfMaxRatio = 88
+)
+
+// Opcodes of the RDB file format.
+const (
+	opSlotInfo       = 0xF4
+	opFunctionPreGA  = 0xF5
+	opFunction2      = 0xF6
+	opModuleAux      = 0xF7
+	opIdle           = 0xF8
+	opFreq           = 0xF9
+	opAux            = 0xFA
+	opResizeDB       = 0xFB
+	opExpireTimeMs   = 0xFC
+	opExpireTime     = 0xFD
+	opSelectDB       = 0xFE
+	opEOF            = 0xFF
+	moduleOpcodeEOF  = 0
+	moduleOpcodeSInt = 1
+	moduleOpcodeUInt = 2
+	moduleOpcodeFlt  = 3
+	moduleOpcodeDbl  = 4
+	moduleOpcodeStr  = 5
+)
+
+// Value types of the RDB file format.
+const (
+	typeString              = 0
+	typeList                = 1
+	typeSet                 = 2
+	typeZSet                = 3
+	typeHash                = 4
+	typeZSet2               = 5
+	typeModulePreGA         = 6
+	typeModule2             = 7
+	typeHashZipmap          = 9
+	typeListZiplist         = 10
+	typeSetIntset           = 11
+	typeZSetZiplist         = 12
+	typeHashZiplist         = 13
+	typeListQuicklist       = 14
+	typeStreamListpacks     = 15
+	typeHashListpack        = 16
+	typeZSetListpack        = 17
+	typeListQuicklist2      = 18
+	typeStreamListpacks2    = 19
+	typeSetListpack         = 20
+	typeStreamListpacks3    = 21
+	typeHashMetadataPreGA   = 22
+	typeHashListpackExPreGA = 23
+	typeHashMetadata        = 24
+	typeHashListpackEx      = 25
+)
+
+// Quicklist node containers.
+const (
+	quicklistPlain  = 1
+	quicklistPacked = 2
+)
+
+// Entry is a key loaded from an RDB file.
+type Entry struct {
+	Type     string           // Type as reported by TYPE, e.g. "hash", or the name of a module type
+	Value    string           // Value of strings
+	Elements []values.Element // Elements of hashes, lists, sets and sorted sets
+	ExpireAt time.Time        // Zero if the key doesn't expire
+	Idle     int64            // LRU idle time in seconds, -1 if not recorded
+	Freq     int64            // LFU frequency, -1 if not recorded
+}
+
+// File is the content of an RDB file.
+type File struct {
+	Version   int
+	Aux       map[string]string         // Auxiliary fields, e.g. redis-ver
+	DBs       map[int]map[string]*Entry // Keys by database index
+	Functions []string                  // Code of the function libraries
+	Skipped   int                       // Number of module aux fields skipped
+}
+
+// Load parses the RDB file at path.
+func Load(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(bufio.NewReaderSize(f, 1<<16))
+}
+
+// Parse parses an RDB file. The checksum at the end of the file is not verified.
+func Parse(r io.Reader) (*File, error) {
+	p := &parser{r: r}
+	header := make([]byte, 9)
+	if err := p.full(header); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(header[:5]) != "REDIS" {
+		return nil, errors.New("not an RDB file")
+	}
+	version, err := strconv.Atoi(string(header[5:]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RDB version %q", header[5:])
+	}
+	if version < minVersion || version > maxVersion {
+		return nil, fmt.Errorf("unsupported RDB version %d", version)
+	}
+
+	file := &File{
+		Version: version,
+		Aux:     make(map[string]string),
+		DBs:     make(map[int]map[string]*Entry),
+	}
+	if err := p.parse(file); err != nil {
+		return nil, fmt.Errorf("offset %d: %w", p.offset, err)
+	}
+	log.Printf("Parsed RDB file version %d with %d databases", version, len(file.DBs))
+	return file, nil
+}
+
+type parser struct {
+	r      io.Reader
+	offset int64
+	buf    [8]byte
+}
+
+func (p *parser) parse(file *File) error {
+	db := 0
+	var expireAt time.Time
+	idle, freq := int64(-1), int64(-1)
+	for {
+		op, err := p.byte()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case opEOF:
+			return nil
+
+		case opSelectDB:
+			n, err := p.length()
+			if err != nil {
+				return err
+			}
+			db = int(n)
+
+		case opResizeDB:
+			if _, err := p.length(); err != nil {
+				return err
+			}
+			if _, err := p.length(); err != nil {
+				return err
+			}
+
+		case opSlotInfo:
+			for range 3 {
+				if _, err := p.length(); err != nil {
+					return err
+				}
+			}
+
+		case opExpireTime:
+			if err := p.full(p.buf[:4]); err != nil {
+				return err
+			}
+			expireAt = time.Unix(int64(binary.LittleEndian.Uint32(p.buf[:4])), 0)
+
+		case opExpireTimeMs:
+			ms, err := p.millis()
+			if err != nil {
+				return err
+			}
+			expireAt = time.UnixMilli(ms)
+
+		case opIdle:
+			n, err := p.length()
+			if err != nil {
+				return err
+			}
+			idle = int64(n)
+
+		case opFreq:
+			b, err := p.byte()
+			if err != nil {
+				return err
+			}
+			freq = int64(b)
+
+		case opAux:
+			k, err := p.string()
+			if err != nil {
+				return err
+			}
+			v, err := p.string()
+			if err != nil {
+				return err
+			}
+			file.Aux[k] = v
+
+		case opModuleAux:
+			if _, err := p.length(); err != nil { // Module ID
+				return err
+			}
+			if _, err := p.length(); err != nil { // When opcode
+				return err
+			}
+			if _, err := p.length(); err != nil { // When
+				return err
+			}
+			if err := p.skipModuleValue(); err != nil {
+				return fmt.Errorf("failed to skip module aux field: %w", err)
+			}
+			file.Skipped++
+
+		case opFunction2:
+			code, err := p.string()
+			if err != nil {
+				return err
+			}
+			file.Functions = append(file.Functions, code)
+
+		case opFunctionPreGA:
+			return errors.New("functions saved by a pre-release version of Redis 7 are not supported")
+
+		default:
+			key, err := p.string()
+			if err != nil {
+				return err
+			}
+			e, err := p.value(op)
+			if err != nil {
+				return fmt.Errorf("key %q: %w", key, err)
+			}
+			e.ExpireAt, e.Idle, e.Freq = expireAt, idle, freq
+			if file.DBs[db] == nil {
+				file.DBs[db] = make(map[string]*Entry)
+			}
+			file.DBs[db][key] = e
+			expireAt, idle, freq = time.Time{}, -1, -1
+		}
+	}
+}
+
+// value parses a value of type t.
+func (p *parser) value(t byte) (*Entry, error) {
+	switch t {
+	case typeString:
+		s, err := p.string()
+		return &Entry{Type: "string", Value: s}, err
+
+	case typeList, typeSet:
+		elements, err := p.strings(1)
+		if t == typeList {
+			return &Entry{Type: "list", Elements: elements}, err
+		}
+		return &Entry{Type: "set", Elements: elements}, err
+
+	case typeHash:
+		elements, err := p.strings(2)
+		return &Entry{Type: "hash", Elements: elements}, err
+
+	case typeZSet, typeZSet2:
+		n, err := p.length()
+		if err != nil {
+			return nil, err
+		}
+		elements := make([]values.Element, 0, min(n, 1<<16))
+		for range n {
+			member, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			var score float64
+			if t == typeZSet2 {
+				if err := p.full(p.buf[:8]); err != nil {
+					return nil, err
+				}
+				score = math.Float64frombits(binary.LittleEndian.Uint64(p.buf[:8]))
+			} else if score, err = p.stringDouble(); err != nil {
+				return nil, err
+			}
+			elements = append(elements, values.Element{Field: member, Score: score})
+		}
+		sortZSet(elements)
+		return &Entry{Type: "zset", Elements: elements}, nil
+
+	case typeHashMetadataPreGA, typeHashMetadata:
+		if t == typeHashMetadata {
+			if _, err := p.millis(); err != nil { // Minimum expiration time of the fields
+				return nil, err
+			}
+		}
+		n, err := p.length()
+		if err != nil {
+			return nil, err
+		}
+		elements := make([]values.Element, 0, min(n, 1<<16))
+		for range n {
+			if _, err := p.length(); err != nil { // Expiration time of the field
+				return nil, err
+			}
+			field, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			value, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, values.Element{Field: field, Value: value})
+		}
+		return &Entry{Type: "hash", Elements: elements}, nil
+
+	case typeHashZipmap:
+		blob, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		items, err := zipmap([]byte(blob))
+		return &Entry{Type: "hash", Elements: pairs(items, 2)}, err
+
+	case typeListZiplist, typeZSetZiplist, typeHashZiplist:
+		blob, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		items, err := ziplist([]byte(blob))
+		if err != nil {
+			return nil, err
+		}
+		return packedEntry(t, items)
+
+	case typeHashListpack, typeZSetListpack, typeSetListpack, typeHashListpackExPreGA, typeHashListpackEx:
+		if t == typeHashListpackEx {
+			if _, err := p.millis(); err != nil { // Minimum expiration time of the fields
+				return nil, err
+			}
+		}
+		blob, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		items, err := listpack([]byte(blob))
+		if err != nil {
+			return nil, err
+		}
+		return packedEntry(t, items)
+
+	case typeSetIntset:
+		blob, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		items, err := intset([]byte(blob))
+		return &Entry{Type: "set", Elements: pairs(items, 1)}, err
+
+	case typeListQuicklist, typeListQuicklist2:
+		return p.quicklist(t)
+
+	case typeStreamListpacks, typeStreamListpacks2, typeStreamListpacks3:
+		// Stream entries can't be displayed yet, but the key is listed
+		return &Entry{Type: "stream"}, p.skipStream(t)
+
+	case typeModule2:
+		id, err := p.length()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.skipModuleValue(); err != nil {
+			return nil, err
+		}
+		return &Entry{Type: moduleName(id)}, nil
+
+	case typeModulePreGA:
+		return nil, errors.New("module values saved by a pre-release version of Redis 4 are not supported")
+
+	default:
+		return nil, fmt.Errorf("unknown value type %d", t)
+	}
+}
+
+// packedEntry builds the entry of a ziplist or listpack encoded value from its items.
+func packedEntry(t byte, items []string) (*Entry, error) {
+	switch t {
+	case typeListZiplist:
+		return &Entry{Type: "list", Elements: pairs(items, 1)}, nil
+	case typeSetListpack:
+		return &Entry{Type: "set", Elements: pairs(items, 1)}, nil
+	case typeHashZiplist, typeHashListpack:
+		return &Entry{Type: "hash", Elements: pairs(items, 2)}, nil
+	case typeHashListpackEx, typeHashListpackExPreGA:
+		// Items are triplets of field, value and expiration time
+		elements := make([]values.Element, 0, len(items)/3)
+		for i := 0; i+2 < len(items); i += 3 {
+			elements = append(elements, values.Element{Field: items[i], Value: items[i+1]})
+		}
+		return &Entry{Type: "hash", Elements: elements}, nil
+	default: // Sorted sets
+		elements := make([]values.Element, 0, len(items)/2)
+		for i := 0; i+1 < len(items); i += 2 {
+			score, err := strconv.ParseFloat(items[i+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid score %q", items[i+1])
+			}
+			elements = append(elements, values.Element{Field: items[i], Score: score})
+		}
+		sortZSet(elements)
+		return &Entry{Type: "zset", Elements: elements}, nil
+	}
+}
+
+// pairs turns items into elements, reading fields and values for step 2.
+func pairs(items []string, step int) []values.Element {
+	elements := make([]values.Element, 0, len(items)/step)
+	for i := 0; i+step-1 < len(items); i += step {
+		e := values.Element{Field: items[i]}
+		if step == 2 {
+			e.Value = items[i+1]
+		}
+		elements = append(elements, e)
+	}
+	return elements
+}
+
+// sortZSet orders members by score then lexicographically, as ZRANGE does.
+// Skiplist encoded sorted sets are saved from the greatest to the smallest member.
+func sortZSet(elements []values.Element) {
+	sort.SliceStable(elements, func(i, j int) bool {
+		if elements[i].Score != elements[j].Score {
+			return elements[i].Score < elements[j].Score
+		}
+		return elements[i].Field < elements[j].Field
+	})
+}
+
+func (p *parser) quicklist(t byte) (*Entry, error) {
+	n, err := p.length()
+	if err != nil {
+		return nil, err
+	}
+	var items []string
+	for range n {
+		container := uint64(quicklistPacked)
+		if t == typeListQuicklist2 {
+			if container, err = p.length(); err != nil {
+				return nil, err
+			}
+		}
+		blob, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case container == quicklistPlain:
+			items = append(items, blob)
+			continue
+		case t == typeListQuicklist2:
+			node, err := listpack([]byte(blob))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, node...)
+		default:
+			node, err := ziplist([]byte(blob))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, node...)
+		}
+	}
+	return &Entry{Type: "list", Elements: pairs(items, 1)}, nil
+}
+
+// skipStream reads past a stream, including its consumer groups.
+func (p *parser) skipStream(t byte) error {
+	n, err := p.length()
+	if err != nil {
+		return err
+	}
+	for range n * 2 { // Master ID and listpack of each node
+		if _, err := p.string(); err != nil {
+			return err
+		}
+	}
+	lengths := 3 // Number of entries and last ID
+	if t >= typeStreamListpacks2 {
+		lengths += 5 // First ID, max deleted ID and entries added
+	}
+	if err := p.skipLengths(lengths); err != nil {
+		return err
+	}
+
+	groups, err := p.length()
+	if err != nil {
+		return err
+	}
+	for range groups {
+		if _, err := p.string(); err != nil { // Name
+			return err
+		}
+		lengths := 2 // Last ID
+		if t >= typeStreamListpacks2 {
+			lengths++ // Entries read
+		}
+		if err := p.skipLengths(lengths); err != nil {
+			return err
+		}
+		pending, err := p.length()
+		if err != nil {
+			return err
+		}
+		for range pending {
+			if err := p.skip(16 + 8); err != nil { // ID and delivery time
+				return err
+			}
+			if _, err := p.length(); err != nil { // Delivery count
+				return err
+			}
+		}
+		consumers, err := p.length()
+		if err != nil {
+			return err
+		}
+		for range consumers {
+			if _, err := p.string(); err != nil { // Name
+				return err
+			}
+			times := int64(8) // Seen time
+			if t >= typeStreamListpacks3 {
+				times += 8 // Active time
+			}
+			if err := p.skip(times); err != nil {
+				return err
+			}
+			pending, err := p.length()
+			if err != nil {
+				return err
+			}
+			if err := p.skip(int64(pending) * 16); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipModuleValue reads past a value serialized by a module, which is made of typed opcodes.
+func (p *parser) skipModuleValue() error {
+	for {
+		op, err := p.length()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case moduleOpcodeEOF:
+			return nil
+		case moduleOpcodeSInt, moduleOpcodeUInt:
+			_, err = p.length()
+		case moduleOpcodeFlt:
+			err = p.skip(4)
+		case moduleOpcodeDbl:
+			err = p.skip(8)
+		case moduleOpcodeStr:
+			_, err = p.string()
+		default:
+			return fmt.Errorf("unknown module opcode %d", op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// moduleName decodes the name of a module type, made of 9 characters packed in the
+// upper 54 bits of the module ID. The lower 10 bits are the encoding version.
+func moduleName(id uint64) string {
+	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	name := make([]byte, 9)
+	for i := range name {
+		name[i] = charset[(id>>(64-6*(i+1)))&63]
+	}
+	return string(name)
+}
+
+func (p *parser) full(b []byte) error {
+	n, err := io.ReadFull(p.r, b)
+	p.offset += int64(n)
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// bytes reads n bytes, a length read from the file.
+func (p *parser) bytes(n uint64) ([]byte, error) {
+	if n > maxStringLength {
+		return nil, fmt.Errorf("invalid string length %d", n)
+	}
+	if n <= readChunk {
+		b := make([]byte, n)
+		return b, p.full(b)
+	}
+	var buf bytes.Buffer
+	m, err := io.CopyN(&buf, p.r, int64(n))
+	p.offset += m
+	if errors.Is(err, io.EOF) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), err
+}
+
+func (p *parser) skip(n int64) error {
+	m, err := io.CopyN(io.Discard, p.r, n)
+	p.offset += m
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (p *parser) skipLengths(n int) error {
+	for range n {
+		if _, err := p.length(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) byte() (byte, error) {
+	if err := p.full(p.buf[:1]); err != nil {
+		return 0, err
+	}
+	return p.buf[0], nil
+}
+
+func (p *parser) millis() (int64, error) {
+	if err := p.full(p.buf[:8]); err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(p.buf[:8])), nil
+}
+
+// Special string encodings, flagged by a length starting with bits 11.
+const (
+	encInt8  = 0
+	encInt16 = 1
+	encInt32 = 2
+	encLZF   = 3
+)
+
+// rawLength reads a length, and reports whether it is a special string encoding instead.
+func (p *parser) rawLength() (uint64, bool, error) {
+	b, err := p.byte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3F), false, nil
+	case 1:
+		next, err := p.byte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(b&0x3F)<<8 | uint64(next), false, nil
+	case 2:
+		switch b {
+		case 0x80:
+			if err := p.full(p.buf[:4]); err != nil {
+				return 0, false, err
+			}
+			return uint64(binary.BigEndian.Uint32(p.buf[:4])), false, nil
+		case 0x81:
+			if err := p.full(p.buf[:8]); err != nil {
+				return 0, false, err
+			}
+			return binary.BigEndian.Uint64(p.buf[:8]), false, nil
+		default:
+			return 0, false, fmt.Errorf("invalid length encoding 0x%02x", b)
+		}
+	default:
+		return uint64(b & 0x3F), true, nil
+	}
+}
+
+func (p *parser) length() (uint64, error) {
+	n, special, err := p.rawLength()
+	if err != nil {
+		return 0, err
+	}
+	if special {
+		return 0, fmt.Errorf("unexpected string encoding %d for a length", n)
+	}
+	return n, nil
+}
+
+func (p *parser) string() (string, error) {
+	n, special, err := p.rawLength()
+	if err != nil {
+		return "", err
+	}
+	if !special {
+		b, err := p.bytes(n)
+		return string(b), err
+	}
+
+	switch n {
+	case encInt8:
+		b, err := p.byte()
+		return strconv.Itoa(int(int8(b))), err
+	case encInt16:
+		if err := p.full(p.buf[:2]); err != nil {
+			return "", err
+		}
+		return strconv.Itoa(int(int16(binary.LittleEndian.Uint16(p.buf[:2])))), nil
+	case encInt32:
+		if err := p.full(p.buf[:4]); err != nil {
+			return "", err
+		}
+		return strconv.Itoa(int(int32(binary.LittleEndian.Uint32(p.buf[:4])))), nil
+	case encLZF:
+		clen, err := p.length()
+		if err != nil {
+			return "", err
+		}
+		ulen, err := p.length()
+		if err != nil {
+			return "", err
+		}
+		if ulen > maxStringLength || ulen > clen*lzfMaxRatio {
+			return "", fmt.Errorf("invalid LZF string length %d for %d compressed bytes", ulen, clen)
+		}
+		compressed, err := p.bytes(clen)
+		if err != nil {
+			return "", err
+		}
+		b, err := lzfDecompress(compressed, int(ulen))
+		return string(b), err
+	default:
+		return "", fmt.Errorf("unknown string encoding %d", n)
+	}
+}
+
+// strings reads a length followed by as many strings times step, as elements.
+func (p *parser) strings(step int) ([]values.Element, error) {
+	n, err := p.length()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]string, 0, min(n*uint64(step), 1<<16))
+	for range n * uint64(step) {
+		s, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+	return pairs(items, step), nil
+}
+
+// stringDouble reads a score of the old sorted set encoding, saved as text.
+func (p *parser) stringDouble() (float64, error) {
+	n, err := p.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+	b := make([]byte, n)
+	if err := p.full(b); err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(b), 64)
+}
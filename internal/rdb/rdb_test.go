@@ -0,0 +1,199 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hirotake111/redisclient/internal/values"
+)
+
+// str encodes a string shorter than 64 bytes.
+func str(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// file returns an RDB file of version 11 made of the parts, selecting database 0 first.
+func file(parts ...[]byte) []byte {
+	b := []byte("REDIS0011")
+	b = append(b, opSelectDB, 0)
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func cat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func le64(v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, v)
+}
+
+var eof = []byte{opEOF}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		key  string
+		want Entry
+	}{
+		{
+			name: "string",
+			in:   file(cat([]byte{typeString}, str("k"), str("v")), eof),
+			key:  "k",
+			want: Entry{Type: "string", Value: "v"},
+		},
+		{
+			name: "integer strings",
+			in:   file(cat([]byte{typeString}, str("k"), []byte{0xC1, 0x39, 0x30}), eof),
+			key:  "k",
+			want: Entry{Type: "string", Value: "12345"},
+		},
+		{
+			name: "negative int8",
+			in:   file(cat([]byte{typeString}, str("k"), []byte{0xC0, 0xFF}), eof),
+			key:  "k",
+			want: Entry{Type: "string", Value: "-1"},
+		},
+		{
+			name: "lzf",
+			// A literal "a", then a back reference copying it 9 times
+			in:   file(cat([]byte{typeString}, str("k"), []byte{0xC3, 5, 10, 0x00, 'a', 0xE0, 0x00, 0x00}), eof),
+			key:  "k",
+			want: Entry{Type: "string", Value: "aaaaaaaaaa"},
+		},
+		{
+			name: "expiration",
+			in:   file(cat([]byte{opExpireTimeMs}, le64(1700000000000), []byte{typeString}, str("k"), str("v")), eof),
+			key:  "k",
+			want: Entry{Type: "string", Value: "v", ExpireAt: time.UnixMilli(1700000000000)},
+		},
+		{
+			name: "list",
+			in:   file(cat([]byte{typeList}, str("k"), []byte{2}, str("a"), str("b")), eof),
+			key:  "k",
+			want: Entry{Type: "list", Elements: []values.Element{{Field: "a"}, {Field: "b"}}},
+		},
+		{
+			name: "hash",
+			in:   file(cat([]byte{typeHash}, str("k"), []byte{1}, str("f"), str("v")), eof),
+			key:  "k",
+			want: Entry{Type: "hash", Elements: []values.Element{{Field: "f", Value: "v"}}},
+		},
+		{
+			name: "sorted set",
+			in: file(cat([]byte{typeZSet2}, str("k"), []byte{2},
+				str("b"), le64(math.Float64bits(2)), str("a"), le64(math.Float64bits(math.Inf(-1)))), eof),
+			key:  "k",
+			want: Entry{Type: "zset", Elements: []values.Element{{Field: "a", Score: math.Inf(-1)}, {Field: "b", Score: 2}}},
+		},
+		{
+			name: "intset",
+			in:   file(cat([]byte{typeSetIntset}, str("k"), str("\x02\x00\x00\x00\x02\x00\x00\x00\x01\x00\xFF\xFF")), eof),
+			key:  "k",
+			want: Entry{Type: "set", Elements: []values.Element{{Field: "1"}, {Field: "-1"}}},
+		},
+		{
+			name: "listpack hash",
+			in: file(cat([]byte{typeHashListpack}, str("k"),
+				str("\x00\x00\x00\x00\x02\x00\x81f\x02\x05\x01\xFF")), eof),
+			key:  "k",
+			want: Entry{Type: "hash", Elements: []values.Element{{Field: "f", Value: "5"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(bytes.NewReader(tt.in))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			got := f.DBs[0][tt.key]
+			if got == nil {
+				t.Fatalf("key %q not found in %v", tt.key, f.DBs)
+			}
+			tt.want.Idle, tt.want.Freq = -1, -1
+			if got.Type != tt.want.Type || got.Value != tt.want.Value || !got.ExpireAt.Equal(tt.want.ExpireAt) ||
+				got.Idle != tt.want.Idle || got.Freq != tt.want.Freq || !slices.Equal(got.Elements, tt.want.Elements) {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAux(t *testing.T) {
+	f, err := Parse(bytes.NewReader(file(cat([]byte{opAux}, str("redis-ver"), str("7.2.0")), eof)))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if f.Version != 11 || f.Aux["redis-ver"] != "7.2.0" {
+		t.Errorf("got version %d and aux fields %v", f.Version, f.Aux)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want string // Part of the error
+	}{
+		{name: "empty", in: nil, want: "header"},
+		{name: "truncated header", in: []byte("REDIS00"), want: "header"},
+		{name: "not rdb", in: []byte("RADIS0011"), want: "not an RDB file"},
+		{name: "unsupported version", in: []byte("REDIS0099"), want: "unsupported RDB version"},
+		{name: "no eof", in: file(cat([]byte{typeString}, str("k"), str("v"))), want: "unexpected EOF"},
+		{name: "truncated string", in: file([]byte{typeString, 1, 'k', 10, 'a', 'b'}), want: "unexpected EOF"},
+		{name: "truncated length", in: file([]byte{typeString, 1, 'k', 0x80, 0xFF}), want: "unexpected EOF"},
+		{
+			// Read in chunks, failing at the end of the file rather than allocating 4GB
+			name: "length past the end",
+			in:   file([]byte{typeString, 1, 'k', 0x80, 0xFF, 0xFF, 0xFF, 0xF0, 'a'}),
+			want: "unexpected EOF",
+		},
+		{
+			name: "oversized length",
+			in:   file([]byte{typeString, 1, 'k', 0x81, 0xFF, 0, 0, 0, 0, 0, 0, 0}),
+			want: "invalid string length",
+		},
+		{name: "invalid length encoding", in: file([]byte{typeString, 1, 'k', 0x82}), want: "invalid length encoding"},
+		{name: "unknown string encoding", in: file([]byte{typeString, 1, 'k', 0xC4}), want: "unknown string encoding"},
+		{
+			name: "oversized lzf length",
+			in:   file([]byte{typeString, 1, 'k', 0xC3, 2, 0x80, 0x7F, 0xFF, 0xFF, 0xFF, 0x00, 'a'}),
+			want: "invalid LZF string length",
+		},
+		{
+			name: "lzf length mismatch",
+			in:   file(cat([]byte{typeString, 1, 'k', 0xC3, 2, 3, 0x00, 'a'}, eof)),
+			want: "LZF decompressed 1 bytes, expected 3",
+		},
+		{name: "unknown type", in: file(cat([]byte{0x42}, str("k")), eof), want: "unknown value type"},
+		{
+			name: "truncated ziplist",
+			in:   file(cat([]byte{typeListZiplist}, str("k"), str("\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x05ab")), eof),
+			want: errTruncated.Error(),
+		},
+		{
+			name: "oversized intset",
+			in:   file(cat([]byte{typeSetIntset}, str("k"), str("\x02\x00\x00\x00\xFF\xFF\xFF\xFF\x01\x00")), eof),
+			want: errTruncated.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(bytes.NewReader(tt.in))
+			if err == nil {
+				t.Fatalf("Parse = %+v, want an error", f)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse failed with %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hirotake111/redisclient/internal/rdb"
+	"github.com/hirotake111/redisclient/internal/values"
+	"github.com/redis/go-redis/v9"
+)
+
+// Memory browses keys loaded in memory, e.g. from an RDB file. It is read-only.
+type Memory struct {
+	name string
+	dbs  map[int]map[string]*rdb.Entry
+	db   int
+}
+
+// NewMemory returns a source browsing dbs, keys by database index, identified by name.
+func NewMemory(name string, dbs map[int]map[string]*rdb.Entry) *Memory {
+	return &Memory{name: name, dbs: dbs}
+}
+
+// NewRDB returns a source browsing an RDB file.
+func NewRDB(file *rdb.File, path string) *Memory {
+	return NewMemory("rdb:"+filepath.Base(path), file.DBs)
+}
+
+func (m *Memory) Name() string {
+	return m.name
+}
+
+func (m *Memory) DB() int {
+	return m.db
+}
+
+func (m *Memory) SelectDB(ctx context.Context, db int) (Source, error) {
+	return &Memory{name: m.name, dbs: m.dbs, db: db}, nil
+}
+
+func (m *Memory) Client() *redis.Client {
+	return nil
+}
+
+func (m *Memory) Keys(ctx context.Context, pattern string) ([]string, error) {
+	keys := make([]string, 0, len(m.dbs[m.db]))
+	for k := range m.dbs[m.db] {
+		if Match(pattern, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (m *Memory) Type(ctx context.Context, key string) (string, error) {
+	e, ok := m.dbs[m.db][key]
+	if !ok {
+		return "none", nil
+	}
+	return e.Type, nil
+}
+
+func (m *Memory) Get(ctx context.Context, key string) (string, error) {
+	e, ok := m.dbs[m.db][key]
+	if !ok {
+		return "", redis.Nil
+	}
+	if e.Type != "string" {
+		return "", fmt.Errorf("key %s is a %s, not a string", key, e.Type)
+	}
+	return e.Value, nil
+}
+
+// TTL is computed from the expiration time of the key, so keys that expired
+// since the keys were saved are still listed, without a TTL.
+func (m *Memory) TTL(ctx context.Context, key string) (time.Duration, error) {
+	e, ok := m.dbs[m.db][key]
+	if !ok {
+		return -2, nil
+	}
+	if e.ExpireAt.IsZero() {
+		return -1, nil
+	}
+	return time.Until(e.ExpireAt), nil
+}
+
+// Page returns the elements from the offset cursor.
+func (m *Memory) Page(ctx context.Context, key, t string, cursor uint64, pageSize int64) (values.Collection, error) {
+	page := values.Collection{Type: t}
+	e, ok := m.dbs[m.db][key]
+	if !ok || e.Type != t {
+		return page, fmt.Errorf("key %s of type %s not found", key, t)
+	}
+	page.Total = int64(len(e.Elements))
+	start := min(cursor, uint64(len(e.Elements)))
+	end := min(start+uint64(pageSize), uint64(len(e.Elements)))
+	page.Elements = e.Elements[start:end:end]
+	page.Cursor = end
+	page.Done = end == uint64(len(e.Elements))
+	return page, nil
+}
+
+// Metrics measures keys from the attributes saved with them. The idle time or
+// the frequency is saved depending on the eviction policy of the server.
+func (m *Memory) Metrics(ctx context.Context, keys []string, metric string) (string, map[string]int64, error) {
+	entries := m.dbs[m.db]
+	if metric == MetricAccess {
+		metric = MetricIdle
+		for _, e := range entries {
+			if e.Freq >= 0 {
+				metric = MetricFreq
+				break
+			}
+		}
+	}
+
+	metrics := make(map[string]int64, len(keys))
+	for _, k := range keys {
+		var v int64 = -1
+		if e, ok := entries[k]; ok {
+			switch metric {
+			case MetricTTL:
+				if !e.ExpireAt.IsZero() {
+					v = max(int64(time.Until(e.ExpireAt).Seconds()), 0)
+				}
+			case MetricIdle:
+				v = e.Idle
+			case MetricFreq:
+				v = e.Freq
+			default:
+				return metric, nil, fmt.Errorf("%w in %s: %s", ErrMetric, m.name, metric)
+			}
+		}
+		metrics[k] = v
+	}
+	return metric, metrics, nil
+}
@@ -0,0 +1,192 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hirotake111/redisclient/internal/values"
+	"github.com/redis/go-redis/v9"
+)
+
+// Server browses a Redis server.
+type Server struct {
+	client *redis.Client
+}
+
+func NewServer(client *redis.Client) *Server {
+	return &Server{client: client}
+}
+
+func (s *Server) Name() string {
+	return s.client.Options().Addr
+}
+
+func (s *Server) DB() int {
+	return s.client.Options().DB
+}
+
+// SelectDB connects to another database of the same server.
+func (s *Server) SelectDB(ctx context.Context, db int) (Source, error) {
+	opt := *s.client.Options()
+	opt.DB = db
+	nc := redis.NewClient(&opt)
+	if _, err := nc.Ping(ctx).Result(); err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+	return NewServer(nc), nil
+}
+
+func (s *Server) Client() *redis.Client {
+	return s.client
+}
+
+func (s *Server) Keys(ctx context.Context, pattern string) ([]string, error) {
+	return s.client.Keys(ctx, pattern).Result()
+}
+
+func (s *Server) Type(ctx context.Context, key string) (string, error) {
+	return s.client.Type(ctx, key).Result()
+}
+
+func (s *Server) Get(ctx context.Context, key string) (string, error) {
+	return s.client.Get(ctx, key).Result()
+}
+
+func (s *Server) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return s.client.TTL(ctx, key).Result()
+}
+
+// Page scans hashes and sets with HSCAN/SSCAN, and reads lists and sorted sets
+// with windowed LRANGE/ZRANGE so that their order is preserved.
+func (s *Server) Page(ctx context.Context, key, t string, cursor uint64, pageSize int64) (values.Collection, error) {
+	client := s.client
+	page := values.Collection{Type: t}
+	var err error
+
+	switch t {
+	case "hash":
+		page.Total, err = client.HLen(ctx, key).Result()
+		if err != nil {
+			return page, err
+		}
+		var kvs []string
+		kvs, page.Cursor, err = client.HScan(ctx, key, cursor, "", pageSize).Result()
+		if err != nil {
+			return page, err
+		}
+		for i := 0; i+1 < len(kvs); i += 2 {
+			page.Elements = append(page.Elements, values.Element{Field: kvs[i], Value: kvs[i+1]})
+		}
+		page.Done = page.Cursor == 0
+
+	case "set":
+		page.Total, err = client.SCard(ctx, key).Result()
+		if err != nil {
+			return page, err
+		}
+		var members []string
+		members, page.Cursor, err = client.SScan(ctx, key, cursor, "", pageSize).Result()
+		if err != nil {
+			return page, err
+		}
+		for _, m := range members {
+			page.Elements = append(page.Elements, values.Element{Field: m})
+		}
+		page.Done = page.Cursor == 0
+
+	case "list":
+		page.Total, err = client.LLen(ctx, key).Result()
+		if err != nil {
+			return page, err
+		}
+		items, err := client.LRange(ctx, key, int64(cursor), int64(cursor)+pageSize-1).Result()
+		if err != nil {
+			return page, err
+		}
+		for _, it := range items {
+			page.Elements = append(page.Elements, values.Element{Field: it})
+		}
+		page.Cursor = cursor + uint64(len(items))
+		page.Done = len(items) < int(pageSize) || int64(page.Cursor) >= page.Total
+
+	case "zset":
+		page.Total, err = client.ZCard(ctx, key).Result()
+		if err != nil {
+			return page, err
+		}
+		zs, err := client.ZRangeWithScores(ctx, key, int64(cursor), int64(cursor)+pageSize-1).Result()
+		if err != nil {
+			return page, err
+		}
+		for _, z := range zs {
+			page.Elements = append(page.Elements, values.Element{Field: fmt.Sprint(z.Member), Score: z.Score})
+		}
+		page.Cursor = cursor + uint64(len(zs))
+		page.Done = len(zs) < int(pageSize) || int64(page.Cursor) >= page.Total
+
+	default:
+		return page, fmt.Errorf("type %s can't be paged", t)
+	}
+	return page, nil
+}
+
+// Metrics fetches the metric of every key in a single pipeline.
+func (s *Server) Metrics(ctx context.Context, keys []string, metric string) (string, map[string]int64, error) {
+	if metric == MetricAccess {
+		metric = s.accessMetric(ctx)
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]redis.Cmder, 0, len(keys))
+	for _, k := range keys {
+		switch metric {
+		case MetricTTL:
+			cmds = append(cmds, pipe.TTL(ctx, k))
+		case MetricMemory:
+			cmds = append(cmds, pipe.MemoryUsage(ctx, k))
+		case MetricIdle:
+			cmds = append(cmds, pipe.ObjectIdleTime(ctx, k))
+		case MetricFreq:
+			cmds = append(cmds, pipe.ObjectFreq(ctx, k))
+		default:
+			return metric, nil, fmt.Errorf("unknown metric %s", metric)
+		}
+	}
+	// Errors are checked per command below, as a missing key fails only its own command
+	_, _ = pipe.Exec(ctx)
+
+	metrics := make(map[string]int64, len(keys))
+	for i, c := range cmds {
+		var v int64 = -1
+		switch c := c.(type) {
+		case *redis.DurationCmd:
+			if d, err := c.Result(); err == nil && d >= 0 {
+				v = int64(d.Seconds())
+			}
+		case *redis.IntCmd:
+			if n, err := c.Result(); err == nil {
+				v = n
+			}
+		}
+		metrics[keys[i]] = v
+	}
+	return metric, metrics, nil
+}
+
+// accessMetric returns MetricFreq when the server runs an LFU eviction policy
+// (OBJECT FREQ fails otherwise), and MetricIdle for everything else.
+func (s *Server) accessMetric(ctx context.Context) string {
+	cfg, err := s.client.ConfigGet(ctx, "maxmemory-policy").Result()
+	if err != nil {
+		log.Printf("Error fetching maxmemory-policy: %v", err)
+		return MetricIdle
+	}
+	if strings.Contains(cfg["maxmemory-policy"], "lfu") {
+		return MetricFreq
+	}
+	return MetricIdle
+}
@@ -0,0 +1,51 @@
+// Package source provides the keys and values browsed in the app, from a Redis server or from a file.
+package source
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hirotake111/redisclient/internal/values"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrReadOnly is returned when modifying a source that can only be browsed.
+var ErrReadOnly = errors.New("the data source is read-only")
+
+// ErrMetric is returned for metrics that a source can't measure.
+var ErrMetric = errors.New("metric not available")
+
+// Metric names used for sorting keys by server-side attributes.
+const (
+	MetricTTL    = "ttl"
+	MetricMemory = "memory"
+	MetricAccess = "access" // Resolved to MetricIdle or MetricFreq depending on maxmemory-policy
+	MetricIdle   = "idle"
+	MetricFreq   = "freq"
+)
+
+// Source is where keys and values are browsed from.
+type Source interface {
+	// Name identifies the source in the UI, e.g. the address of the server.
+	Name() string
+	// DB returns the index of the selected database.
+	DB() int
+	// SelectDB returns the same source with another database selected.
+	SelectDB(ctx context.Context, db int) (Source, error)
+	// Client returns the client of the Redis server, or nil if the source is read-only.
+	Client() *redis.Client
+
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	// Type returns the type of the key, or "none" if it doesn't exist.
+	Type(ctx context.Context, key string) (string, error)
+	// Get returns the value of a string.
+	Get(ctx context.Context, key string) (string, error)
+	// TTL returns the remaining time to live of the key, or a negative duration if it doesn't expire.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Page returns about pageSize elements of a hash, list, set or sorted set starting at cursor.
+	Page(ctx context.Context, key, t string, cursor uint64, pageSize int64) (values.Collection, error)
+	// Metrics measures every key, returning the metric actually measured (see MetricAccess).
+	// Keys that no longer exist or can't be measured get a metric of -1.
+	Metrics(ctx context.Context, keys []string, metric string) (string, map[string]int64, error)
+}
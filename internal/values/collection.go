@@ -0,0 +1,72 @@
+package values
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Element is a single element of a hash, list, set or sorted set.
+type Element struct {
+	Field string  // Hash field, sorted set member, or list/set element
+	Value string  // Hash value
+	Score float64 // Sorted set score
+}
+
+// Collection holds the elements of a hash, list, set or sorted set loaded so far.
+type Collection struct {
+	Type     string    // Redis type of the key
+	Elements []Element // Elements in the order returned by the server
+	Total    int64     // Total number of elements in the key
+	Cursor   uint64    // Scan cursor, or range offset, of the next page
+	Done     bool      // Whether every element has been loaded
+}
+
+// Append adds the elements of the next page to the collection.
+// The returned collection doesn't share its elements with c, which may be cached.
+func (c Collection) Append(page Collection) Collection {
+	elements := make([]Element, 0, len(c.Elements)+len(page.Elements))
+	elements = append(elements, c.Elements...)
+	c.Elements = append(elements, page.Elements...)
+	c.Total = page.Total
+	c.Cursor = page.Cursor
+	c.Done = page.Done
+	return c
+}
+
+// JSON renders the elements as a JSON document, keeping the server order.
+// Hashes and sorted sets are rendered as objects, lists and sets as arrays.
+func (c Collection) JSON() string {
+	var sb strings.Builder
+	isObject := c.Type == "hash" || c.Type == "zset"
+	if isObject {
+		sb.WriteByte('{')
+	} else {
+		sb.WriteByte('[')
+	}
+	for i, e := range c.Elements {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(quote(e.Field))
+		switch c.Type {
+		case "hash":
+			sb.WriteByte(':')
+			sb.WriteString(quote(e.Value))
+		case "zset":
+			sb.WriteByte(':')
+			sb.WriteString(strconv.FormatFloat(e.Score, 'g', -1, 64))
+		}
+	}
+	if isObject {
+		sb.WriteByte('}')
+	} else {
+		sb.WriteByte(']')
+	}
+	return sb.String()
+}
+
+func quote(s string) string {
+	b, _ := json.Marshal(s) // Marshaling a string never fails
+	return string(b)
+}
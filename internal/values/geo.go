@@ -0,0 +1,42 @@
+package values
+
+import "math"
+
+const (
+	// geoStep is the number of bits of each coordinate in the 52-bit geohash of a score.
+	geoStep = 26
+	// MaxLatitude is the latitude of the poles of the Web Mercator projection, beyond which
+	// positions can't be indexed by GEOADD.
+	MaxLatitude = 85.05112878
+)
+
+// LooksGeo reports whether the collection is a sorted set that may be a geo index:
+// every score loaded is a 52-bit geohash. Scores below 2^48 are not taken for geohashes,
+// as they would be positions of the South Pacific and counters or timestamps in
+// milliseconds are more likely. Timestamps in microseconds still look like geohashes,
+// so this is only a hint.
+func (c Collection) LooksGeo() bool {
+	if c.Type != "zset" || len(c.Elements) == 0 {
+		return false
+	}
+	for _, e := range c.Elements {
+		if e.Score != math.Trunc(e.Score) || e.Score < 1<<48 || e.Score >= 1<<52 {
+			return false
+		}
+	}
+	return true
+}
+
+// DecodeGeohash returns the longitude and latitude of a geohash score, the center of its
+// area like GEOPOS returns it. The even bits of the score are those of the latitude, and
+// the odd bits those of the longitude.
+func DecodeGeohash(score float64) (float64, float64) {
+	hash := uint64(score)
+	var lat, lon uint64
+	for i := range geoStep {
+		lat |= (hash >> (2 * i) & 1) << i
+		lon |= (hash >> (2*i + 1) & 1) << i
+	}
+	cells := float64(uint64(1) << geoStep)
+	return -180 + (float64(lon)+0.5)/cells*360, -MaxLatitude + (float64(lat)+0.5)/cells*2*MaxLatitude
+}